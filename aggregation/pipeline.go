@@ -0,0 +1,388 @@
+package aggregation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0B1t322/MongoBuilder/operators/sort"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidStage           = errors.New("stage must contain exactly one field")
+	ErrStageMustBeLast        = errors.New("stage must be the last stage in the pipeline")
+	ErrStageMustBeFirst       = errors.New("stage must be the first stage in the pipeline")
+	ErrStageNotAllowedInFacet = errors.New("stage is not allowed in $facet")
+)
+
+var notAllowedInFacetStages = map[string]bool{
+	"$collStats":      true,
+	"$facet":          true,
+	"$geoNear":        true,
+	"$indexStats":     true,
+	"$out":            true,
+	"$merge":          true,
+	"$planCacheStats": true,
+}
+
+type PipelineBuilder interface {
+	// Append stages to the end of pipeline
+	Append(stages ...bson.M) PipelineBuilder
+	// Append stages of others pipelines to the end of pipeline
+	Concat(others ...PipelineBuilder) PipelineBuilder
+	// Return a copy of pipeline, appending to the copy does not change the original
+	Clone() PipelineBuilder
+	Stages() []bson.M
+
+	AddFields(args ...AddFieldsArger) PipelineBuilder
+	Bucket(arg BucketArger) PipelineBuilder
+	BucketAuto(arg BucketAutoArger) PipelineBuilder
+	Count(field string) PipelineBuilder
+	Densify(arg DensifyArger) PipelineBuilder
+	Facet(arg FacetArger) PipelineBuilder
+	Fill(arg FillArger) PipelineBuilder
+	GeoNear(arg GeoNearArger) PipelineBuilder
+	GraphLookup(arg GraphLookupArger) PipelineBuilder
+	Group(arg GroupArger) PipelineBuilder
+	Limit(limit int) PipelineBuilder
+	Lookup(arg LookupArger) PipelineBuilder
+	Match(query interface{}) PipelineBuilder
+	Merge(arg MergeArger) PipelineBuilder
+	OutCollection(collection string) PipelineBuilder
+	OutDatabase(database, collection string) PipelineBuilder
+	Projection(arg ProjectionArger) PipelineBuilder
+	Redact(expression interface{}) PipelineBuilder
+	ReplaceRoot(newRoot interface{}) PipelineBuilder
+	ReplaceWith(replaceDocument interface{}) PipelineBuilder
+	Sample(size interface{}) PipelineBuilder
+	Set(args ...AddFieldsArger) PipelineBuilder
+	SetWindowFields(arg SetWindowFieldsArger) PipelineBuilder
+	Skip(skip interface{}) PipelineBuilder
+	Sort(args ...sort.SortArger) PipelineBuilder
+	SortByCount(expression interface{}) PipelineBuilder
+	UnionWith(arg UnionWithArger) PipelineBuilder
+	Unset(arg UnsetArger) PipelineBuilder
+	Unwind(arg UnwindArger) PipelineBuilder
+
+	// Return error if pipeline is invalid
+	Build() (mongo.Pipeline, error)
+	// Work like Build but return bson.A
+	BuildArray() (bson.A, error)
+
+	// Return the first error of stages that are built with error, e.g. Facet
+	getErr() error
+}
+
+type pipeline struct {
+	stages []bson.M
+	err    error
+}
+
+func Pipeline(stages ...bson.M) PipelineBuilder {
+	p := &pipeline{stages: []bson.M{}}
+	return p.Append(stages...)
+}
+
+func (p *pipeline) Append(stages ...bson.M) PipelineBuilder {
+	p.stages = append(p.stages, stages...)
+	return p
+}
+
+func (p *pipeline) Concat(others ...PipelineBuilder) PipelineBuilder {
+	for _, other := range others {
+		p.setErr(other.getErr())
+		p.Append(other.Stages()...)
+	}
+	return p
+}
+
+func (p *pipeline) Clone() PipelineBuilder {
+	return &pipeline{
+		stages: append([]bson.M{}, p.stages...),
+		err:    p.err,
+	}
+}
+
+func (p *pipeline) getErr() error {
+	return p.err
+}
+
+// Keep the first error
+func (p *pipeline) setErr(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *pipeline) Stages() []bson.M {
+	return p.stages
+}
+
+func (p *pipeline) AddFields(args ...AddFieldsArger) PipelineBuilder {
+	return p.Append(AddFields(args...))
+}
+
+func (p *pipeline) Bucket(arg BucketArger) PipelineBuilder {
+	return p.Append(Bucket(arg))
+}
+
+func (p *pipeline) BucketAuto(arg BucketAutoArger) PipelineBuilder {
+	return p.Append(BucketAuto(arg))
+}
+
+func (p *pipeline) Count(field string) PipelineBuilder {
+	return p.Append(Count(field))
+}
+
+func (p *pipeline) Densify(arg DensifyArger) PipelineBuilder {
+	return p.Append(Densify(arg))
+}
+
+// If facet is invalid the error is returned by Build
+func (p *pipeline) Facet(arg FacetArger) PipelineBuilder {
+	stage, err := Facet(arg)
+	if err != nil {
+		p.setErr(err)
+		return p
+	}
+	return p.Append(stage)
+}
+
+func (p *pipeline) Fill(arg FillArger) PipelineBuilder {
+	return p.Append(Fill(arg))
+}
+
+func (p *pipeline) GeoNear(arg GeoNearArger) PipelineBuilder {
+	return p.Append(GeoNear(arg))
+}
+
+func (p *pipeline) GraphLookup(arg GraphLookupArger) PipelineBuilder {
+	return p.Append(GraphLookup(arg))
+}
+
+func (p *pipeline) Group(arg GroupArger) PipelineBuilder {
+	return p.Append(Group(arg))
+}
+
+func (p *pipeline) Limit(limit int) PipelineBuilder {
+	return p.Append(Limit(limit))
+}
+
+func (p *pipeline) Lookup(arg LookupArger) PipelineBuilder {
+	return p.Append(Lookup(arg))
+}
+
+func (p *pipeline) Match(query interface{}) PipelineBuilder {
+	return p.Append(Match(query))
+}
+
+func (p *pipeline) Merge(arg MergeArger) PipelineBuilder {
+	return p.Append(Merge(arg))
+}
+
+func (p *pipeline) OutCollection(collection string) PipelineBuilder {
+	return p.Append(OutCollection(collection))
+}
+
+func (p *pipeline) OutDatabase(database, collection string) PipelineBuilder {
+	return p.Append(OutDatabase(database, collection))
+}
+
+func (p *pipeline) Projection(arg ProjectionArger) PipelineBuilder {
+	return p.Append(Projection(arg))
+}
+
+func (p *pipeline) Redact(expression interface{}) PipelineBuilder {
+	return p.Append(Redact(expression))
+}
+
+func (p *pipeline) ReplaceRoot(newRoot interface{}) PipelineBuilder {
+	return p.Append(ReplaceRoot(newRoot))
+}
+
+func (p *pipeline) ReplaceWith(replaceDocument interface{}) PipelineBuilder {
+	return p.Append(ReplaceWith(replaceDocument))
+}
+
+func (p *pipeline) Sample(size interface{}) PipelineBuilder {
+	return p.Append(Sample(size))
+}
+
+func (p *pipeline) Set(args ...AddFieldsArger) PipelineBuilder {
+	return p.Append(Set(args...))
+}
+
+func (p *pipeline) SetWindowFields(arg SetWindowFieldsArger) PipelineBuilder {
+	return p.Append(SetWindowFields(arg))
+}
+
+func (p *pipeline) Skip(skip interface{}) PipelineBuilder {
+	return p.Append(Skip(skip))
+}
+
+func (p *pipeline) Sort(args ...sort.SortArger) PipelineBuilder {
+	return p.Append(Sort(args...))
+}
+
+func (p *pipeline) SortByCount(expression interface{}) PipelineBuilder {
+	return p.Append(SortByCount(expression))
+}
+
+func (p *pipeline) UnionWith(arg UnionWithArger) PipelineBuilder {
+	return p.Append(UnionWith(arg))
+}
+
+func (p *pipeline) Unset(arg UnsetArger) PipelineBuilder {
+	return p.Append(Unset(arg))
+}
+
+func (p *pipeline) Unwind(arg UnwindArger) PipelineBuilder {
+	return p.Append(Unwind(arg))
+}
+
+func (p *pipeline) Build() (mongo.Pipeline, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	out := mongo.Pipeline{}
+	for _, stage := range p.stages {
+		name, _ := stageName(stage)
+		out = append(out, bson.D{{Key: name, Value: stage[name]}})
+	}
+	return out, nil
+}
+
+func (p *pipeline) BuildArray() (bson.A, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	out := bson.A{}
+	for _, stage := range p.stages {
+		out = append(out, stage)
+	}
+	return out, nil
+}
+
+func (p *pipeline) validate() error {
+	if p.err != nil {
+		return p.err
+	}
+
+	for i, stage := range p.stages {
+		name, err := stageName(stage)
+		if err != nil {
+			return fmt.Errorf("%w: position %d", err, i)
+		}
+
+		switch name {
+		case "$out", "$merge":
+			if i != len(p.stages)-1 {
+				return fmt.Errorf("%w: %s at position %d", ErrStageMustBeLast, name, i)
+			}
+		case "$geoNear":
+			if i != 0 {
+				return fmt.Errorf("%w: %s at position %d", ErrStageMustBeFirst, name, i)
+			}
+		case "$facet":
+			if err := validateFacet(stage[name]); err != nil {
+				return fmt.Errorf("%w at position %d", err, i)
+			}
+		}
+	}
+	return nil
+}
+
+func validateFacet(facet interface{}) error {
+	var fields bson.M
+	switch f := facet.(type) {
+	case bson.M:
+		fields = f
+	case map[string]interface{}:
+		fields = f
+	case bson.D:
+		fields = f.Map()
+	default:
+		return fmt.Errorf("%w: $facet must be a document", ErrInvalidStage)
+	}
+
+	for field, value := range fields {
+		stages, err := facetStages(value)
+		if err != nil {
+			return fmt.Errorf("%w: in $facet output %s", err, field)
+		}
+
+		if err := validateFacetStages(field, stages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return stages of sub-pipeline, ErrInvalidStage if it's not an array of documents
+func facetStages(value interface{}) ([]bson.M, error) {
+	var elements []interface{}
+	switch v := value.(type) {
+	case []bson.M:
+		return v, nil
+	case mongo.Pipeline:
+		for _, stage := range v {
+			elements = append(elements, stage)
+		}
+	case []bson.D:
+		for _, stage := range v {
+			elements = append(elements, stage)
+		}
+	case bson.A:
+		elements = v
+	case []interface{}:
+		elements = v
+	default:
+		return nil, ErrInvalidStage
+	}
+
+	stages := []bson.M{}
+	for _, element := range elements {
+		switch stage := element.(type) {
+		case bson.M:
+			stages = append(stages, stage)
+		case map[string]interface{}:
+			stages = append(stages, stage)
+		case bson.D:
+			if len(stage) != 1 {
+				return nil, ErrInvalidStage
+			}
+			stages = append(stages, stage.Map())
+		default:
+			return nil, ErrInvalidStage
+		}
+	}
+	return stages, nil
+}
+
+func validateFacetStages(field string, stages []bson.M) error {
+	for _, stage := range stages {
+		name, err := stageName(stage)
+		if err != nil {
+			return fmt.Errorf("%w: in $facet output %s", err, field)
+		}
+
+		if notAllowedInFacetStages[name] {
+			return fmt.Errorf("%w: %s in output %s", ErrStageNotAllowedInFacet, name, field)
+		}
+	}
+	return nil
+}
+
+func stageName(stage bson.M) (string, error) {
+	if len(stage) != 1 {
+		return "", ErrInvalidStage
+	}
+
+	for name := range stage {
+		return name, nil
+	}
+	return "", ErrInvalidStage
+}
@@ -0,0 +1,191 @@
+package aggregation_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0B1t322/MongoBuilder/aggregation"
+	op "github.com/0B1t322/MongoBuilder/operators/aggregation"
+	"github.com/0B1t322/MongoBuilder/operators/query"
+	"github.com/0B1t322/MongoBuilder/operators/sort"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestFunc_Pipeline(t *testing.T) {
+	t.Run(
+		"Build",
+		func(t *testing.T) {
+			pipeline, err := aggregation.Pipeline().
+				Match(query.EQField("status", "A")).
+				Group(
+					aggregation.GroupArg().
+						GroupBy("$cust_id").
+						AddField("total", op.Sum("$amount")),
+				).
+				Sort(sort.SortArg("total", sort.DESC())).
+				OutCollection("totals").
+				Build()
+			require.NoError(t, err)
+			require.Equal(
+				t,
+				mongo.Pipeline{
+					{{Key: "$match", Value: bson.M{"status": "A"}}},
+					{{Key: "$group", Value: bson.M{
+						"_id":   "$cust_id",
+						"total": bson.M{"$sum": "$amount"},
+					}}},
+					{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}}}},
+					{{Key: "$out", Value: "totals"}},
+				},
+				pipeline,
+			)
+		},
+	)
+
+	t.Run(
+		"BuildArray",
+		func(t *testing.T) {
+			array, err := aggregation.Pipeline(aggregation.Limit(10)).
+				Skip(5).
+				BuildArray()
+			require.NoError(t, err)
+			require.Equal(
+				t,
+				bson.A{
+					bson.M{"$limit": 10},
+					bson.M{"$skip": 5},
+				},
+				array,
+			)
+		},
+	)
+
+	t.Run(
+		"CloneAndConcat",
+		func(t *testing.T) {
+			base := aggregation.Pipeline().Match(query.EQField("status", "A"))
+			page := base.Clone().Skip(20).Limit(10)
+			count := base.Clone().Count("total")
+
+			require.Equal(
+				t,
+				[]bson.M{
+					{"$match": bson.M{"status": "A"}},
+				},
+				base.Stages(),
+			)
+
+			require.Equal(
+				t,
+				[]bson.M{
+					{"$match": bson.M{"status": "A"}},
+					{"$skip": 20},
+					{"$limit": 10},
+					{"$count": "total"},
+				},
+				aggregation.Pipeline().Concat(page, aggregation.Pipeline(count.Stages()[1:]...)).Stages(),
+			)
+		},
+	)
+
+	t.Run(
+		"OutNotLast",
+		func(t *testing.T) {
+			_, err := aggregation.Pipeline().
+				OutCollection("totals").
+				Limit(1).
+				Build()
+			require.True(t, errors.Is(err, aggregation.ErrStageMustBeLast))
+
+			_, err = aggregation.Pipeline().
+				Merge(aggregation.MergeArg().IntoCollection("totals")).
+				Match(query.EQField("a", 1)).
+				BuildArray()
+			require.True(t, errors.Is(err, aggregation.ErrStageMustBeLast))
+		},
+	)
+
+	t.Run(
+		"FacetNotAllowedStage",
+		func(t *testing.T) {
+			_, err := aggregation.Pipeline().
+				Facet(
+					aggregation.FacetArg().
+						AddFacet("total", aggregation.Count("count")).
+						AddFacet("saved", aggregation.OutCollection("page")),
+				).
+				Build()
+			require.True(t, errors.Is(err, aggregation.ErrStageNotAllowedInFacet))
+
+			_, err = aggregation.Pipeline().
+				Facet(
+					aggregation.FacetArg().
+						AddFacet("total", aggregation.Count("count")),
+				).
+				Build()
+			require.NoError(t, err)
+
+			// hand-built $facet stages
+			for _, output := range []interface{}{
+				bson.A{bson.M{"$out": "c"}},
+				[]interface{}{bson.D{{Key: "$merge", Value: "c"}}},
+				mongo.Pipeline{{{Key: "$indexStats", Value: bson.M{}}}},
+			} {
+				_, err = aggregation.Pipeline(bson.M{"$facet": bson.M{"x": output}}).Build()
+				require.True(t, errors.Is(err, aggregation.ErrStageNotAllowedInFacet), output)
+			}
+
+			for _, output := range []interface{}{
+				"$out",
+				bson.A{"$out"},
+				bson.A{bson.M{"$limit": 1, "$skip": 1}},
+			} {
+				_, err = aggregation.Pipeline(bson.M{"$facet": bson.M{"x": output}}).Build()
+				require.True(t, errors.Is(err, aggregation.ErrInvalidStage), output)
+			}
+
+			// invalid sub-pipeline of cloned and concatenated pipelines
+			invalid := aggregation.Pipeline().
+				Facet(
+					aggregation.FacetArg().
+						AddFacet("saved", aggregation.OutCollection("page")),
+				)
+			_, err = invalid.Clone().Limit(1).Build()
+			require.True(t, errors.Is(err, aggregation.ErrStageNotAllowedInFacet))
+
+			_, err = aggregation.Pipeline().Concat(invalid).Build()
+			require.True(t, errors.Is(err, aggregation.ErrStageNotAllowedInFacet))
+		},
+	)
+
+	t.Run(
+		"GeoNearNotFirst",
+		func(t *testing.T) {
+			geoNear := aggregation.GeoNearArg().
+				Near(query.GeoPoint(-73.99279, 40.719296)).
+				DistanceField("distance")
+
+			_, err := aggregation.Pipeline().
+				Match(query.EQField("category", "Parks")).
+				GeoNear(geoNear).
+				Build()
+			require.True(t, errors.Is(err, aggregation.ErrStageMustBeFirst))
+
+			_, err = aggregation.Pipeline().
+				GeoNear(geoNear).
+				Limit(5).
+				Build()
+			require.NoError(t, err)
+		},
+	)
+
+	t.Run(
+		"InvalidStage",
+		func(t *testing.T) {
+			_, err := aggregation.Pipeline(bson.M{"$limit": 1, "$skip": 1}).Build()
+			require.True(t, errors.Is(err, aggregation.ErrInvalidStage))
+		},
+	)
+}
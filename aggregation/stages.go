@@ -1,7 +1,11 @@
 package aggregation
 
 import (
+	"fmt"
+
 	"github.com/0B1t322/MongoBuilder/object"
+	op "github.com/0B1t322/MongoBuilder/operators/aggregation"
+	"github.com/0B1t322/MongoBuilder/operators/query"
 	"github.com/0B1t322/MongoBuilder/operators/sort"
 	"github.com/0B1t322/MongoBuilder/utils"
 	"go.mongodb.org/mongo-driver/bson"
@@ -9,42 +13,88 @@ import (
 
 type AddFieldsArger interface {
 	formatAddFieldsArg() bson.M
+	formatAddFieldsArgOrdered() bson.D
+	isOrdered() bool
+	getBuilder() object.ObjectBuilder
 	AddField(field string, value interface{}) AddFieldsArger
 	AddFieldArger(field string, value AddFieldsArger) AddFieldsArger
+	// Stage will be built with bson.D that keep the order of fields
+	Ordered() AddFieldsArger
 }
 
 type addFieldsArger struct {
 	builder object.ObjectBuilder
+	ordered bool
 }
 
-func (a addFieldsArger) formatAddFieldsArg() bson.M {
+func (a *addFieldsArger) formatAddFieldsArg() bson.M {
 	return a.builder.Build()
 }
 
-func (a addFieldsArger) AddField(field string, value interface{}) AddFieldsArger {
+func (a *addFieldsArger) formatAddFieldsArgOrdered() bson.D {
+	return a.builder.BuildOrdered()
+}
+
+func (a *addFieldsArger) isOrdered() bool {
+	return a.ordered
+}
+
+func (a *addFieldsArger) getBuilder() object.ObjectBuilder {
+	return a.builder
+}
+
+func (a *addFieldsArger) Ordered() AddFieldsArger {
+	a.ordered = true
+	return a
+}
+
+func (a *addFieldsArger) AddField(field string, value interface{}) AddFieldsArger {
 	a.builder.AddField(field, value)
 	return a
 }
 
-func (a addFieldsArger) AddFieldArger(field string, value AddFieldsArger) AddFieldsArger {
-	a.builder.AddField(field, value.formatAddFieldsArg())
+// if value is ordered subdocument keeps the order of fields even if parent is not ordered
+func (a *addFieldsArger) AddFieldArger(field string, value AddFieldsArger) AddFieldsArger {
+	if value.isOrdered() {
+		a.builder.AddField(field, value.formatAddFieldsArgOrdered())
+	} else {
+		a.builder.AddField(field, value.getBuilder())
+	}
 	return a
 }
 
 func AddFieldArg() AddFieldsArger {
-	return addFieldsArger{builder: object.Object()}
+	return &addFieldsArger{builder: object.Object()}
+}
+
+// if any of args is ordered return bson.D
+func mergeAddFieldsArgs(args ...AddFieldsArger) interface{} {
+	for _, arg := range args {
+		if arg.isOrdered() {
+			return utils.MergeBsonD(
+				func() (slice []bson.D) {
+					for _, arg := range args {
+						slice = append(slice, arg.formatAddFieldsArgOrdered())
+					}
+					return slice
+				}()...,
+			)
+		}
+	}
+
+	return utils.MergeBsonM(
+		func() (slice []bson.M) {
+			for _, arg := range args {
+				slice = append(slice, arg.formatAddFieldsArg())
+			}
+			return slice
+		}()...,
+	)
 }
 
 func AddFields(args ...AddFieldsArger) bson.M {
 	return bson.M{
-		"$addFields": utils.MergeBsonM(
-			func() (slice []bson.M) {
-				for _, arg := range args {
-					slice = append(slice, arg.formatAddFieldsArg())
-				}
-				return slice
-			}()...,
-		),
+		"$addFields": mergeAddFieldsArgs(args...),
 	}
 }
 
@@ -123,25 +173,732 @@ func Bucket(
 	return arg.formatBucketArg()
 }
 
+type Granularity int
+
+// Preferred number series for $bucketAuto granularity
+const (
+	GranularityR5 Granularity = iota
+	GranularityR10
+	GranularityR20
+	GranularityR40
+	GranularityR80
+	Granularity125
+	GranularityE6
+	GranularityE12
+	GranularityE24
+	GranularityE48
+	GranularityE96
+	GranularityE192
+	GranularityPowersOf2
+)
+
+func (g Granularity) String() string {
+	return []string{
+		"R5",
+		"R10",
+		"R20",
+		"R40",
+		"R80",
+		"1-2-5",
+		"E6",
+		"E12",
+		"E24",
+		"E48",
+		"E96",
+		"E192",
+		"POWERSOF2",
+	}[g]
+}
+
+type BucketAutoArger interface {
+	formatBucketAutoArg() bson.M
+	GroupBy(expression interface{}) BucketAutoArger
+	Buckets(buckets int) BucketAutoArger
+	// if value is ObjectBuilder will build the object
+	Output(value interface{}) BucketAutoArger
+	Granularity(granularity Granularity) BucketAutoArger
+}
+
+type bucketAutoArger struct {
+	groupBy     interface{}
+	buckets     int
+	output      interface{}
+	granularity *Granularity
+}
+
+func (b *bucketAutoArger) formatBucketAutoArg() bson.M {
+	optionals := bson.M{}
+	{
+		if b.output != nil {
+			optionals["output"] = b.output
+		}
+
+		if b.granularity != nil {
+			optionals["granularity"] = b.granularity.String()
+		}
+	}
+	return bson.M{
+		"$bucketAuto": utils.MergeBsonM(
+			bson.M{
+				"groupBy": b.groupBy,
+				"buckets": b.buckets,
+			},
+			optionals,
+		),
+	}
+}
+
+func (b *bucketAutoArger) GroupBy(expression interface{}) BucketAutoArger {
+	b.groupBy = expression
+	return b
+}
+
+func (b *bucketAutoArger) Buckets(buckets int) BucketAutoArger {
+	b.buckets = buckets
+	return b
+}
+
+func (b *bucketAutoArger) Output(value interface{}) BucketAutoArger {
+	if v, ok := value.(object.ObjectBuilder); ok {
+		b.output = v.Build()
+	} else {
+		b.output = value
+	}
+	return b
+}
+
+func (b *bucketAutoArger) Granularity(granularity Granularity) BucketAutoArger {
+	b.granularity = &granularity
+	return b
+}
+
+func BucketAutoArg() BucketAutoArger {
+	return &bucketAutoArger{}
+}
+
+/*
+Categorizes incoming documents into a specific number of groups, called buckets, based on a specified expression.
+
+	{
+		$bucketAuto: {
+			groupBy: <expression>,
+			buckets: <number>,
+			output: {
+				<output1>: { <$accumulator expression> },
+				...
+			}
+			granularity: <string>
+		}
+	}
+*/
+func BucketAuto(
+	arg BucketAutoArger,
+) bson.M {
+	return arg.formatBucketAutoArg()
+}
+
 func Count(field string) bson.M {
 	return bson.M{
 		"$count": field,
 	}
 }
 
+type DensifyRangeArger interface {
+	formatDensifyRangeArg() bson.M
+	// Unit to apply to the step, use if field is date
+	Unit(unit op.TimeUnit) DensifyRangeArger
+	// Densify values in the range spans the full range of values of all documents, it's default
+	Full() DensifyRangeArger
+	// Densify values in each partition in range of values in the partition
+	Partition() DensifyRangeArger
+	// Densify values in range [lower, upper)
+	Bounds(lower, upper interface{}) DensifyRangeArger
+}
+
+type densifyRangeArger struct {
+	step   interface{}
+	unit   *op.TimeUnit
+	bounds interface{}
+}
+
+func (d *densifyRangeArger) formatDensifyRangeArg() bson.M {
+	b := bson.M{
+		"step":   d.step,
+		"bounds": d.bounds,
+	}
+	if d.unit != nil {
+		b["unit"] = d.unit.String()
+	}
+	return b
+}
+
+func (d *densifyRangeArger) Unit(unit op.TimeUnit) DensifyRangeArger {
+	d.unit = &unit
+	return d
+}
+
+func (d *densifyRangeArger) Full() DensifyRangeArger {
+	d.bounds = "full"
+	return d
+}
+
+func (d *densifyRangeArger) Partition() DensifyRangeArger {
+	d.bounds = "partition"
+	return d
+}
+
+func (d *densifyRangeArger) Bounds(lower, upper interface{}) DensifyRangeArger {
+	d.bounds = bson.A{lower, upper}
+	return d
+}
+
+func DensifyRangeArg(step interface{}) DensifyRangeArger {
+	arg := &densifyRangeArger{step: step}
+	return arg.Full()
+}
+
+type DensifyArger interface {
+	formatDensifyArg() bson.M
+	Field(field string) DensifyArger
+	PartitionByFields(fields ...string) DensifyArger
+	Range(arg DensifyRangeArger) DensifyArger
+}
+
+type densifyArger struct {
+	field             string
+	partitionByFields []string
+	densifyRange      DensifyRangeArger
+}
+
+func (d *densifyArger) formatDensifyArg() bson.M {
+	b := bson.M{
+		"field": d.field,
+	}
+	{
+		if len(d.partitionByFields) > 0 {
+			fields := bson.A{}
+			for _, field := range d.partitionByFields {
+				fields = append(fields, field)
+			}
+			b["partitionByFields"] = fields
+		}
+
+		if d.densifyRange != nil {
+			b["range"] = d.densifyRange.formatDensifyRangeArg()
+		}
+	}
+	return bson.M{
+		"$densify": b,
+	}
+}
+
+func (d *densifyArger) Field(field string) DensifyArger {
+	d.field = field
+	return d
+}
+
+func (d *densifyArger) PartitionByFields(fields ...string) DensifyArger {
+	d.partitionByFields = append(d.partitionByFields, fields...)
+	return d
+}
+
+func (d *densifyArger) Range(arg DensifyRangeArger) DensifyArger {
+	d.densifyRange = arg
+	return d
+}
+
+func DensifyArg() DensifyArger {
+	return &densifyArger{}
+}
+
+/*
+Creates new documents in a sequence of documents where certain values in a field are missing.
+
+	{
+		$densify: {
+			field: <fieldName>,
+			partitionByFields: [ <field 1>, <field 2> ... <field n> ],
+			range: {
+				step: <number>,
+				unit: <time unit>,
+				bounds: < "full" || "partition" > || [ < lower bound >, < upper bound > ]
+			}
+		}
+	}
+*/
+func Densify(arg DensifyArger) bson.M {
+	return arg.formatDensifyArg()
+}
+
+type FacetArger interface {
+	formatFacetArg() (bson.M, error)
+	// Add output field with sub-pipeline
+	AddFacet(field string, stages ...bson.M) FacetArger
+	AddFacetPipeline(field string, pipeline PipelineBuilder) FacetArger
+}
+
+type facetArger struct {
+	fields object.ObjectBuilder
+	// first invalid sub-pipeline, returned by Facet
+	err error
+}
+
+func (f *facetArger) formatFacetArg() (bson.M, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.fields.Build(), nil
+}
+
+func (f *facetArger) AddFacet(field string, stages ...bson.M) FacetArger {
+	if err := validateFacetStages(field, stages); err != nil && f.err == nil {
+		f.err = err
+	}
+	f.fields.AddField(field, stages)
+	return f
+}
+
+func (f *facetArger) AddFacetPipeline(field string, pipeline PipelineBuilder) FacetArger {
+	if err := pipeline.getErr(); err != nil && f.err == nil {
+		f.err = err
+	}
+	return f.AddFacet(field, pipeline.Stages()...)
+}
+
+func FacetArg() FacetArger {
+	return &facetArger{fields: object.Object()}
+}
+
+/*
+Processes multiple aggregation pipelines within a single stage on the same set of input documents.
+
+	{ $facet: { <outputField1>: [ <stage1>, <stage2>, ... ], <outputField2>: [ <stage1>, <stage2>, ... ], ... } }
+
+Sub-pipelines can't contain $collStats, $facet, $geoNear, $indexStats, $out, $merge and $planCacheStats stages,
+return ErrStageNotAllowedInFacet if they do and ErrInvalidStage if stage is invalid.
+*/
+func Facet(arg FacetArger) (bson.M, error) {
+	facet, err := arg.formatFacetArg()
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{
+		"$facet": facet,
+	}, nil
+}
+
+type FillOutputArger interface {
+	formatFillOutputArg() bson.M
+}
+
+type fillOutputArger struct {
+	output bson.M
+}
+
+func (f fillOutputArger) formatFillOutputArg() bson.M {
+	return f.output
+}
+
+// Fill missing values with the value of expression
+func FillValue(expression interface{}) FillOutputArger {
+	return fillOutputArger{output: bson.M{"value": expression}}
+}
+
+// Fill missing values using linear interpolation based on surrounding non-null values in the sequence
+func FillLinear() FillOutputArger {
+	return fillOutputArger{output: bson.M{"method": "linear"}}
+}
+
+// Fill missing values with the last non-null value in the sequence
+func FillLocf() FillOutputArger {
+	return fillOutputArger{output: bson.M{"method": "locf"}}
+}
+
+type FillArger interface {
+	formatFillArg() bson.M
+	PartitionBy(expression interface{}) FillArger
+	PartitionByFields(fields ...string) FillArger
+	SortBy(args ...sort.SortArger) FillArger
+	Output(field string, output FillOutputArger) FillArger
+}
+
+type fillArger struct {
+	partitionBy       interface{}
+	partitionByFields []string
+	sortBy            bson.D
+	output            object.ObjectBuilder
+}
+
+func (f *fillArger) formatFillArg() bson.M {
+	b := bson.M{
+		"output": f.output.Build(),
+	}
+	{
+		if f.partitionBy != nil {
+			b["partitionBy"] = f.partitionBy
+		}
+
+		if len(f.partitionByFields) > 0 {
+			fields := bson.A{}
+			for _, field := range f.partitionByFields {
+				fields = append(fields, field)
+			}
+			b["partitionByFields"] = fields
+		}
+
+		if f.sortBy != nil {
+			b["sortBy"] = f.sortBy
+		}
+	}
+	return bson.M{
+		"$fill": b,
+	}
+}
+
+func (f *fillArger) PartitionBy(expression interface{}) FillArger {
+	f.partitionBy = expression
+	return f
+}
+
+func (f *fillArger) PartitionByFields(fields ...string) FillArger {
+	f.partitionByFields = append(f.partitionByFields, fields...)
+	return f
+}
+
+func (f *fillArger) SortBy(args ...sort.SortArger) FillArger {
+	f.sortBy = sort.Spec(args...)
+	return f
+}
+
+func (f *fillArger) Output(field string, output FillOutputArger) FillArger {
+	f.output.AddField(field, output.formatFillOutputArg())
+	return f
+}
+
+func FillArg() FillArger {
+	return &fillArger{output: object.Object()}
+}
+
+/*
+Populates null and missing field values within documents.
+
+	{
+		$fill: {
+			partitionBy: <expression>,
+			partitionByFields: [ <field 1>, <field 2>, ... , <field n> ],
+			sortBy: {
+				<sort field 1>: <sort order>,
+				<sort field 2>: <sort order>,
+				...,
+				<sort field n>: <sort order>
+			},
+			output: {
+				<field 1>: { value: <expression> },
+				<field 2>: { method: <string> },
+				...
+			}
+		}
+	}
+*/
+func Fill(arg FillArger) bson.M {
+	return arg.formatFillArg()
+}
+
+type GeoNearArger interface {
+	formatGeoNearArg() bson.M
+	Near(point query.GeoJSONPoint) GeoNearArger
+	// Use legacy coordinate pair
+	NearLegacy(point query.Coordinates) GeoNearArger
+	DistanceField(field string) GeoNearArger
+	Spherical(spherical bool) GeoNearArger
+	MaxDistance(distance float64) GeoNearArger
+	MinDistance(distance float64) GeoNearArger
+	// You can use query package to build the query
+	Query(query bson.M) GeoNearArger
+	IncludeLocs(field string) GeoNearArger
+	DistanceMultiplier(multiplier float64) GeoNearArger
+	Key(field string) GeoNearArger
+}
+
+type geoNearArger struct {
+	near               interface{}
+	distanceField      string
+	spherical          *bool
+	maxDistance        *float64
+	minDistance        *float64
+	query              bson.M
+	includeLocs        string
+	distanceMultiplier *float64
+	key                string
+}
+
+func (g *geoNearArger) formatGeoNearArg() bson.M {
+	optional := bson.M{}
+	{
+		if g.spherical != nil {
+			optional["spherical"] = *g.spherical
+		}
+
+		if g.maxDistance != nil {
+			optional["maxDistance"] = *g.maxDistance
+		}
+
+		if g.minDistance != nil {
+			optional["minDistance"] = *g.minDistance
+		}
+
+		if g.query != nil {
+			optional["query"] = g.query
+		}
+
+		if g.includeLocs != "" {
+			optional["includeLocs"] = g.includeLocs
+		}
+
+		if g.distanceMultiplier != nil {
+			optional["distanceMultiplier"] = *g.distanceMultiplier
+		}
+
+		if g.key != "" {
+			optional["key"] = g.key
+		}
+	}
+	return bson.M{
+		"$geoNear": utils.MergeBsonM(
+			bson.M{
+				"near":          g.near,
+				"distanceField": g.distanceField,
+			},
+			optional,
+		),
+	}
+}
+
+func (g *geoNearArger) Near(point query.GeoJSONPoint) GeoNearArger {
+	g.near = point.GeoJSON()
+	return g
+}
+
+func (g *geoNearArger) NearLegacy(point query.Coordinates) GeoNearArger {
+	g.near = bson.A{point[0], point[1]}
+	return g
+}
+
+func (g *geoNearArger) DistanceField(field string) GeoNearArger {
+	g.distanceField = field
+	return g
+}
+
+func (g *geoNearArger) Spherical(spherical bool) GeoNearArger {
+	g.spherical = &spherical
+	return g
+}
+
+func (g *geoNearArger) MaxDistance(distance float64) GeoNearArger {
+	g.maxDistance = &distance
+	return g
+}
+
+func (g *geoNearArger) MinDistance(distance float64) GeoNearArger {
+	g.minDistance = &distance
+	return g
+}
+
+func (g *geoNearArger) Query(query bson.M) GeoNearArger {
+	g.query = query
+	return g
+}
+
+func (g *geoNearArger) IncludeLocs(field string) GeoNearArger {
+	g.includeLocs = field
+	return g
+}
+
+func (g *geoNearArger) DistanceMultiplier(multiplier float64) GeoNearArger {
+	g.distanceMultiplier = &multiplier
+	return g
+}
+
+func (g *geoNearArger) Key(field string) GeoNearArger {
+	g.key = field
+	return g
+}
+
+func GeoNearArg() GeoNearArger {
+	return &geoNearArger{}
+}
+
+/*
+Outputs documents in order of nearest to farthest from a specified point.
+
+	{
+		$geoNear: {
+			near: <GeoJSON point or legacy coordinate pair>,
+			distanceField: <string>,
+			spherical: <bool>,
+			maxDistance: <number>,
+			minDistance: <number>,
+			query: <document>,
+			includeLocs: <string>,
+			distanceMultiplier: <number>,
+			key: <string>
+		}
+	}
+
+$geoNear must be the first stage of pipeline, PipelineBuilder.Build return ErrStageMustBeFirst if it's not.
+*/
+func GeoNear(arg GeoNearArger) bson.M {
+	return arg.formatGeoNearArg()
+}
+
+type GraphLookupArger interface {
+	formatGraphLookupArg() bson.M
+	From(from string) GraphLookupArger
+	// Expression that specifies the value of the connectFromField with which to start the recursive search
+	StartWith(expression interface{}) GraphLookupArger
+	ConnectFromField(field string) GraphLookupArger
+	ConnectToField(field string) GraphLookupArger
+	As(as string) GraphLookupArger
+	MaxDepth(depth int) GraphLookupArger
+	DepthField(field string) GraphLookupArger
+	// You can use query package to build the query
+	RestrictSearchWithMatch(query bson.M) GraphLookupArger
+}
+
+type graphLookupArger struct {
+	from                    string
+	startWith               interface{}
+	connectFromField        string
+	connectToField          string
+	as                      string
+	maxDepth                *int
+	depthField              string
+	restrictSearchWithMatch bson.M
+}
+
+func (g *graphLookupArger) formatGraphLookupArg() bson.M {
+	optional := bson.M{}
+	{
+		if g.maxDepth != nil {
+			optional["maxDepth"] = *g.maxDepth
+		}
+
+		if g.depthField != "" {
+			optional["depthField"] = g.depthField
+		}
+
+		if g.restrictSearchWithMatch != nil {
+			optional["restrictSearchWithMatch"] = g.restrictSearchWithMatch
+		}
+	}
+	return bson.M{
+		"$graphLookup": utils.MergeBsonM(
+			bson.M{
+				"from":             g.from,
+				"startWith":        g.startWith,
+				"connectFromField": g.connectFromField,
+				"connectToField":   g.connectToField,
+				"as":               g.as,
+			},
+			optional,
+		),
+	}
+}
+
+func (g *graphLookupArger) From(from string) GraphLookupArger {
+	g.from = from
+	return g
+}
+
+func (g *graphLookupArger) StartWith(expression interface{}) GraphLookupArger {
+	g.startWith = expression
+	return g
+}
+
+func (g *graphLookupArger) ConnectFromField(field string) GraphLookupArger {
+	g.connectFromField = field
+	return g
+}
+
+func (g *graphLookupArger) ConnectToField(field string) GraphLookupArger {
+	g.connectToField = field
+	return g
+}
+
+func (g *graphLookupArger) As(as string) GraphLookupArger {
+	g.as = as
+	return g
+}
+
+func (g *graphLookupArger) MaxDepth(depth int) GraphLookupArger {
+	g.maxDepth = &depth
+	return g
+}
+
+func (g *graphLookupArger) DepthField(field string) GraphLookupArger {
+	g.depthField = field
+	return g
+}
+
+func (g *graphLookupArger) RestrictSearchWithMatch(query bson.M) GraphLookupArger {
+	g.restrictSearchWithMatch = query
+	return g
+}
+
+func GraphLookupArg() GraphLookupArger {
+	return &graphLookupArger{}
+}
+
+/*
+Performs a recursive search on a collection.
+
+	{
+		$graphLookup: {
+			from: <collection>,
+			startWith: <expression>,
+			connectFromField: <string>,
+			connectToField: <string>,
+			as: <string>,
+			maxDepth: <number>,
+			depthField: <string>,
+			restrictSearchWithMatch: <document>
+		}
+	}
+*/
+func GraphLookup(
+	arg GraphLookupArger,
+) bson.M {
+	return arg.formatGraphLookupArg()
+}
+
 type GroupArger interface {
 	formatGroupArg() bson.M
 	// _id field in query
 	GroupBy(expression interface{}) GroupArger
 	AddField(field string, value interface{}) GroupArger
+	// Stage will be built with bson.D that keep the order of fields
+	Ordered() GroupArger
 }
 
 type groupArger struct {
-	_id    interface{}
-	fields object.ObjectBuilder
+	_id     interface{}
+	fields  object.ObjectBuilder
+	ordered bool
 }
 
 func (g *groupArger) formatGroupArg() bson.M {
+	if g.ordered {
+		return bson.M{
+			"$group": utils.MergeBsonD(
+				bson.D{
+					{Key: "_id", Value: g._id},
+				},
+				g.fields.BuildOrdered(),
+			),
+		}
+	}
+
 	return bson.M{
 		"$group": utils.MergeBsonM(
 			bson.M{
@@ -152,6 +909,11 @@ func (g *groupArger) formatGroupArg() bson.M {
 	}
 }
 
+func (g *groupArger) Ordered() GroupArger {
+	g.ordered = true
+	return g
+}
+
 func (g *groupArger) GroupBy(expression interface{}) GroupArger {
 	g._id = expression
 	return g
@@ -297,27 +1059,55 @@ func Match(query interface{}) bson.M {
 
 type ProjectionArger interface {
 	formatProjectionArg() bson.M
+	formatProjectionArgOrdered() bson.D
+	isOrdered() bool
+	getBuilder() object.ObjectBuilder
 	AddField(field string, value interface{}) ProjectionArger
 	AddFieldArger(field string, arg ProjectionArger) ProjectionArger
 	ExcludeField(field string) ProjectionArger
 	IncludeField(field string) ProjectionArger
+	// Stage will be built with bson.D that keep the order of fields
+	Ordered() ProjectionArger
 }
 
 type projectionArger struct {
-	fields object.ObjectBuilder
+	fields  object.ObjectBuilder
+	ordered bool
 }
 
 func (p *projectionArger) formatProjectionArg() bson.M {
 	return p.fields.Build()
 }
 
+func (p *projectionArger) formatProjectionArgOrdered() bson.D {
+	return p.fields.BuildOrdered()
+}
+
+func (p *projectionArger) isOrdered() bool {
+	return p.ordered
+}
+
+func (p *projectionArger) getBuilder() object.ObjectBuilder {
+	return p.fields
+}
+
+func (p *projectionArger) Ordered() ProjectionArger {
+	p.ordered = true
+	return p
+}
+
 func (p *projectionArger) AddField(field string, value interface{}) ProjectionArger {
 	p.fields.AddField(field, value)
 	return p
 }
 
+// if arg is ordered subdocument keeps the order of fields even if parent is not ordered
 func (p *projectionArger) AddFieldArger(field string, arg ProjectionArger) ProjectionArger {
-	p.fields.AddField(field, arg.formatProjectionArg())
+	if arg.isOrdered() {
+		p.fields.AddField(field, arg.formatProjectionArgOrdered())
+	} else {
+		p.fields.AddField(field, arg.getBuilder())
+	}
 	return p
 }
 
@@ -338,6 +1128,12 @@ func ProjectionArg() ProjectionArger {
 func Projection(
 	arg ProjectionArger,
 ) bson.M {
+	if arg.isOrdered() {
+		return bson.M{
+			"$project": arg.formatProjectionArgOrdered(),
+		}
+	}
+
 	return bson.M{
 		"$project": arg.formatProjectionArg(),
 	}
@@ -591,15 +1387,146 @@ func Sample(size interface{}) bson.M {
 
 func Set(args ...AddFieldsArger) bson.M {
 	return bson.M{
-		"$set": utils.MergeBsonM(
-			func() (slice []bson.M) {
-				for _, arg := range args {
-					slice = append(slice, arg.formatAddFieldsArg())
-				}
-				return slice
-			}()...,
-		),
+		"$set": mergeAddFieldsArgs(args...),
+	}
+}
+
+// Window bounds
+const (
+	WindowUnbounded = "unbounded"
+	WindowCurrent   = "current"
+)
+
+type WindowArger interface {
+	formatWindowArg() bson.M
+	// Bounds can be integer, WindowUnbounded or WindowCurrent
+	Documents(lower, upper interface{}) WindowArger
+	// Bounds can be number, WindowUnbounded or WindowCurrent
+	Range(lower, upper interface{}) WindowArger
+	// Unit for range bounds, use if sortBy field is date
+	Unit(unit op.TimeUnit) WindowArger
+}
+
+type windowArger struct {
+	window bson.M
+}
+
+func (w *windowArger) formatWindowArg() bson.M {
+	return w.window
+}
+
+func (w *windowArger) Documents(lower, upper interface{}) WindowArger {
+	w.window["documents"] = bson.A{lower, upper}
+	return w
+}
+
+func (w *windowArger) Range(lower, upper interface{}) WindowArger {
+	w.window["range"] = bson.A{lower, upper}
+	return w
+}
+
+func (w *windowArger) Unit(unit op.TimeUnit) WindowArger {
+	w.window["unit"] = unit.String()
+	return w
+}
+
+func WindowArg() WindowArger {
+	return &windowArger{window: bson.M{}}
+}
+
+type SetWindowFieldsArger interface {
+	formatSetWindowFieldsArg() bson.M
+	PartitionBy(expression interface{}) SetWindowFieldsArger
+	SortBy(args ...sort.SortArger) SetWindowFieldsArger
+	// operator is window or accumulator operator, for example op.Sum or op.Rank
+	//
+	// window is optional, panic if more than one window is passed
+	Output(field string, operator bson.M, window ...WindowArger) SetWindowFieldsArger
+}
+
+type setWindowFieldsArger struct {
+	partitionBy interface{}
+	sortBy      bson.D
+	output      object.ObjectBuilder
+}
+
+func (s *setWindowFieldsArger) formatSetWindowFieldsArg() bson.M {
+	b := bson.M{
+		"output": s.output.Build(),
+	}
+	{
+		if s.partitionBy != nil {
+			b["partitionBy"] = s.partitionBy
+		}
+
+		if s.sortBy != nil {
+			b["sortBy"] = s.sortBy
+		}
+	}
+	return bson.M{
+		"$setWindowFields": b,
+	}
+}
+
+func (s *setWindowFieldsArger) PartitionBy(expression interface{}) SetWindowFieldsArger {
+	s.partitionBy = expression
+	return s
+}
+
+func (s *setWindowFieldsArger) SortBy(args ...sort.SortArger) SetWindowFieldsArger {
+	s.sortBy = sort.Spec(args...)
+	return s
+}
+
+func (s *setWindowFieldsArger) Output(field string, operator bson.M, window ...WindowArger) SetWindowFieldsArger {
+	if len(window) > 1 {
+		panic(fmt.Sprintf("output %s accepts at most one window, got %d", field, len(window)))
+	}
+
+	if len(window) > 0 {
+		operator = utils.MergeBsonM(
+			operator,
+			bson.M{
+				"window": window[0].formatWindowArg(),
+			},
+		)
+	}
+	s.output.AddField(field, operator)
+	return s
+}
+
+func SetWindowFieldsArg() SetWindowFieldsArger {
+	return &setWindowFieldsArger{output: object.Object()}
+}
+
+/*
+Performs operations on a specified span of documents in a collection, known as a window, and returns the results based on the chosen window operator.
+
+	{
+		$setWindowFields: {
+			partitionBy: <expression>,
+			sortBy: {
+				<sort field 1>: <sort order>,
+				<sort field 2>: <sort order>,
+				...,
+				<sort field n>: <sort order>
+			},
+			output: {
+				<output field 1>: {
+					<window operator>: <window operator parameters>,
+					window: {
+						documents: [ <lower boundary>, <upper boundary> ],
+						range: [ <lower boundary>, <upper boundary> ],
+						unit: <time unit>
+					}
+				},
+				...
+			}
+		}
 	}
+*/
+func SetWindowFields(arg SetWindowFieldsArger) bson.M {
+	return arg.formatSetWindowFieldsArg()
 }
 
 func Skip(skip interface{}) bson.M {
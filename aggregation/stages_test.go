@@ -1,14 +1,17 @@
 package aggregation_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/0B1t322/MongoBuilder/aggregation"
 	"github.com/0B1t322/MongoBuilder/object"
 	op "github.com/0B1t322/MongoBuilder/operators/aggregation"
 	"github.com/0B1t322/MongoBuilder/operators/query"
+	"github.com/0B1t322/MongoBuilder/operators/sort"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestFunc_Aggregation(t *testing.T) {
@@ -99,6 +102,298 @@ func TestFunc_Aggregation(t *testing.T) {
 		},
 	)
 
+	t.Run(
+		"Densify",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$densify": bson.M{
+						"field": "timestamp",
+						"range": bson.M{
+							"step":   1,
+							"unit":   "hour",
+							"bounds": bson.A{"2021-05-18T00:00:00.000Z", "2021-05-18T08:00:00.000Z"},
+						},
+					},
+				},
+				aggregation.Densify(
+					aggregation.DensifyArg().
+						Field("timestamp").
+						Range(
+							aggregation.DensifyRangeArg(1).
+								Unit(op.UnitHour).
+								Bounds("2021-05-18T00:00:00.000Z", "2021-05-18T08:00:00.000Z"),
+						),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$densify": bson.M{
+						"field":             "altitude",
+						"partitionByFields": bson.A{"variety"},
+						"range": bson.M{
+							"step":   200,
+							"bounds": "partition",
+						},
+					},
+				},
+				aggregation.Densify(
+					aggregation.DensifyArg().
+						Field("altitude").
+						PartitionByFields("variety").
+						Range(
+							aggregation.DensifyRangeArg(200).Partition(),
+						),
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"Fill",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$fill": bson.M{
+						"partitionBy": bson.M{
+							"restaurant": "$restaurant",
+						},
+						"sortBy": bson.D{
+							{Key: "date", Value: 1},
+						},
+						"output": bson.M{
+							"score": bson.M{
+								"method": "locf",
+							},
+							"price": bson.M{
+								"method": "linear",
+							},
+							"bootsSold": bson.M{
+								"value": 0,
+							},
+						},
+					},
+				},
+				aggregation.Fill(
+					aggregation.FillArg().
+						PartitionBy(bson.M{"restaurant": "$restaurant"}).
+						SortBy(sort.SortArg("date", sort.ASC())).
+						Output("score", aggregation.FillLocf()).
+						Output("price", aggregation.FillLinear()).
+						Output("bootsSold", aggregation.FillValue(0)),
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"Facet",
+		func(t *testing.T) {
+			facet, err := aggregation.Facet(
+				aggregation.FacetArg().
+					AddFacet("total", aggregation.Count("count")).
+					AddFacetPipeline(
+						"page",
+						aggregation.Pipeline().Skip(20).Limit(10),
+					),
+			)
+			require.NoError(t, err)
+			require.Equal(
+				t,
+				bson.M{
+					"$facet": bson.M{
+						"total": []bson.M{
+							{"$count": "count"},
+						},
+						"page": []bson.M{
+							{"$skip": 20},
+							{"$limit": 10},
+						},
+					},
+				},
+				facet,
+			)
+
+			for _, stage := range []bson.M{
+				aggregation.OutCollection("c"),
+				aggregation.Merge(aggregation.MergeArg().IntoCollection("c")),
+				{"$facet": bson.M{}},
+				{"$indexStats": bson.M{}},
+				{"$collStats": bson.M{}},
+			} {
+				_, err = aggregation.Facet(
+					aggregation.FacetArg().
+						AddFacet("total", aggregation.Count("count")).
+						AddFacet("x", stage),
+				)
+				require.True(t, errors.Is(err, aggregation.ErrStageNotAllowedInFacet), stage)
+			}
+		},
+	)
+
+	t.Run(
+		"GeoNear",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$geoNear": bson.M{
+						"near": bson.M{
+							"type":        "Point",
+							"coordinates": bson.A{-73.99279, 40.719296},
+						},
+						"distanceField": "dist.calculated",
+						"maxDistance":   2.0,
+						"query": bson.M{
+							"category": "Parks",
+						},
+						"includeLocs": "dist.location",
+						"spherical":   true,
+					},
+				},
+				aggregation.GeoNear(
+					aggregation.GeoNearArg().
+						Near(query.GeoPoint(-73.99279, 40.719296)).
+						DistanceField("dist.calculated").
+						MaxDistance(2).
+						Query(query.EQField("category", "Parks")).
+						IncludeLocs("dist.location").
+						Spherical(true),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$geoNear": bson.M{
+						"near":               bson.A{-73.99279, 40.719296},
+						"distanceField":      "distance",
+						"distanceMultiplier": 6378.1,
+						"key":                "location",
+					},
+				},
+				aggregation.GeoNear(
+					aggregation.GeoNearArg().
+						NearLegacy(query.Coord(-73.99279, 40.719296)).
+						DistanceField("distance").
+						DistanceMultiplier(6378.1).
+						Key("location"),
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"GraphLookup",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$graphLookup": bson.M{
+						"from":             "employees",
+						"startWith":        "$reportsTo",
+						"connectFromField": "reportsTo",
+						"connectToField":   "name",
+						"as":               "reportingHierarchy",
+					},
+				},
+				aggregation.GraphLookup(
+					aggregation.GraphLookupArg().
+						From("employees").
+						StartWith("$reportsTo").
+						ConnectFromField("reportsTo").
+						ConnectToField("name").
+						As("reportingHierarchy"),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$graphLookup": bson.M{
+						"from":             "people",
+						"startWith":        "$friends",
+						"connectFromField": "friends",
+						"connectToField":   "name",
+						"as":               "golfers",
+						"maxDepth":         2,
+						"depthField":       "connections",
+						"restrictSearchWithMatch": bson.M{
+							"hobbies": "golf",
+						},
+					},
+				},
+				aggregation.GraphLookup(
+					aggregation.GraphLookupArg().
+						From("people").
+						StartWith("$friends").
+						ConnectFromField("friends").
+						ConnectToField("name").
+						As("golfers").
+						MaxDepth(2).
+						DepthField("connections").
+						RestrictSearchWithMatch(query.EQField("hobbies", "golf")),
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"BucketAuto",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$bucketAuto": bson.M{
+						"groupBy": "$price",
+						"buckets": 5,
+						"output": bson.M{
+							"count": bson.M{
+								"$sum": 1,
+							},
+							"avgPrice": bson.M{
+								"$avg": "$price",
+							},
+						},
+						"granularity": "1-2-5",
+					},
+				},
+				aggregation.BucketAuto(
+					aggregation.BucketAutoArg().
+						GroupBy("$price").
+						Buckets(5).
+						Output(
+							object.Object().
+								AddField("count", op.Sum(1)).
+								AddField("avgPrice", op.Avg("$price")),
+						).
+						Granularity(aggregation.Granularity125),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$bucketAuto": bson.M{
+						"groupBy":     "$size",
+						"buckets":     3,
+						"granularity": "POWERSOF2",
+					},
+				},
+				aggregation.BucketAuto(
+					aggregation.BucketAutoArg().
+						GroupBy("$size").
+						Buckets(3).
+						Granularity(aggregation.GranularityPowersOf2),
+				),
+			)
+		},
+	)
+
 	t.Run(
 		"Group",
 		func(t *testing.T) {
@@ -126,6 +421,125 @@ func TestFunc_Aggregation(t *testing.T) {
 		},
 	)
 
+	t.Run(
+		"JavaScript",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$group": bson.M{
+						"_id": "$author",
+						"avgCopies": bson.M{
+							"$accumulator": bson.M{
+								"init":           primitive.JavaScript("function() { return { count: 0, sum: 0 } }"),
+								"accumulate":     "function(state, numCopies) { return { count: state.count + 1, sum: state.sum + numCopies } }",
+								"accumulateArgs": bson.A{"$copies"},
+								"merge":          "function(state1, state2) { return { count: state1.count + state2.count, sum: state1.sum + state2.sum } }",
+								"finalize":       "function(state) { return (state.sum / state.count) }",
+								"lang":           "js",
+							},
+						},
+					},
+				},
+				aggregation.Group(
+					aggregation.GroupArg().
+						GroupBy("$author").
+						AddField(
+							"avgCopies",
+							op.Accumulator(
+								op.AccumulatorArg(
+									op.FunctionJavaScript("function() { return { count: 0, sum: 0 } }"),
+									op.FunctionCode("function(state, numCopies) { return { count: state.count + 1, sum: state.sum + numCopies } }"),
+									op.FunctionCode("function(state1, state2) { return { count: state1.count + state2.count, sum: state1.sum + state2.sum } }"),
+								).
+									AccumulateArgs("$copies").
+									Finalize(op.FunctionCode("function(state) { return (state.sum / state.count) }")),
+							),
+						),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$addFields": bson.M{
+						"isFound": bson.M{
+							"$function": bson.M{
+								"body": "function(name) { return hex_md5(name) == \"15b0a220baa16331e8d80e15367677ad\" }",
+								"args": bson.A{"$name"},
+								"lang": "js",
+							},
+						},
+					},
+				},
+				aggregation.AddFields(
+					aggregation.AddFieldArg().
+						AddField(
+							"isFound",
+							op.Function(
+								op.FunctionCode("function(name) { return hex_md5(name) == \"15b0a220baa16331e8d80e15367677ad\" }"),
+								"$name",
+							),
+						),
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"GroupAccumulators",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$group": bson.M{
+						"_id":      "$gameId",
+						"maxScore": bson.M{"$max": "$score"},
+						"topThree": bson.M{
+							"$topN": bson.M{
+								"n": 3,
+								"sortBy": bson.D{
+									{Key: "score", Value: -1},
+									{Key: "playerId", Value: 1},
+								},
+								"output": bson.A{"$playerId", "$score"},
+							},
+						},
+						"lastTwo": bson.M{
+							"$lastN": bson.M{
+								"input": "$score",
+								"n":     2,
+							},
+						},
+						"scorePercentiles": bson.M{
+							"$percentile": bson.M{
+								"input":  "$score",
+								"p":      bson.A{0.5, 0.95},
+								"method": "approximate",
+							},
+						},
+					},
+				},
+				aggregation.Group(
+					aggregation.GroupArg().
+						GroupBy("$gameId").
+						AddField("maxScore", op.Max("$score")).
+						AddField(
+							"topThree",
+							op.TopN(
+								3,
+								bson.A{"$playerId", "$score"},
+								sort.SortArg("score", sort.DESC()),
+								sort.SortArg("playerId", sort.ASC()),
+							),
+						).
+						AddField("lastTwo", op.LastN("$score", 2)).
+						AddField("scorePercentiles", op.Percentile("$score", 0.5, 0.95)),
+				),
+			)
+		},
+	)
+
 	t.Run(
 		"Lookup",
 		func(t *testing.T) {
@@ -251,6 +665,142 @@ func TestFunc_Aggregation(t *testing.T) {
 		},
 	)
 
+	t.Run(
+		"Ordered",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$addFields": bson.D{
+						{Key: "b", Value: 1},
+						{Key: "a", Value: bson.D{
+							{Key: "z", Value: 1},
+							{Key: "y", Value: 2},
+						}},
+					},
+				},
+				aggregation.AddFields(
+					aggregation.AddFieldArg().
+						Ordered().
+						AddField("b", 1).
+						AddFieldArger(
+							"a",
+							aggregation.AddFieldArg().
+								AddField("z", 1).
+								AddField("y", 2),
+						),
+				),
+			)
+
+			// ordered without chaining
+			arg := aggregation.AddFieldArg()
+			arg.Ordered()
+			arg.AddField("b", 1)
+			arg.AddField("a", 2)
+			require.Equal(
+				t,
+				bson.M{
+					"$addFields": bson.D{
+						{Key: "b", Value: 1},
+						{Key: "a", Value: 2},
+					},
+				},
+				aggregation.AddFields(arg),
+			)
+
+			// ordered subdocument in not ordered parent
+			require.Equal(
+				t,
+				bson.M{
+					"$addFields": bson.M{
+						"a": bson.D{
+							{Key: "z", Value: 1},
+							{Key: "y", Value: 2},
+						},
+					},
+				},
+				aggregation.AddFields(
+					aggregation.AddFieldArg().
+						AddFieldArger(
+							"a",
+							aggregation.AddFieldArg().
+								Ordered().
+								AddField("z", 1).
+								AddField("y", 2),
+						),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$project": bson.M{
+						"_id": 0,
+						"author": bson.D{
+							{Key: "name", Value: 1},
+							{Key: "email", Value: 1},
+						},
+					},
+				},
+				aggregation.Projection(
+					aggregation.ProjectionArg().
+						ExcludeField("_id").
+						AddFieldArger(
+							"author",
+							aggregation.ProjectionArg().
+								Ordered().
+								IncludeField("name").
+								IncludeField("email"),
+						),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$project": bson.D{
+						{Key: "title", Value: 1},
+						{Key: "_id", Value: 0},
+						{Key: "author", Value: 1},
+					},
+				},
+				aggregation.Projection(
+					aggregation.ProjectionArg().
+						Ordered().
+						IncludeField("title").
+						ExcludeField("_id").
+						IncludeField("author"),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$group": bson.D{
+						{Key: "_id", Value: "$item"},
+						{Key: "last", Value: bson.M{"$last": "$date"}},
+						{Key: "key", Value: bson.D{
+							{Key: "year", Value: "$year"},
+							{Key: "month", Value: "$month"},
+						}},
+					},
+				},
+				aggregation.Group(
+					aggregation.GroupArg().
+						Ordered().
+						GroupBy("$item").
+						AddField("last", op.Last("$date")).
+						AddField(
+							"key",
+							object.Object().
+								AddField("year", "$year").
+								AddField("month", "$month"),
+						),
+				),
+			)
+		},
+	)
+
 	t.Run(
 		"Projection",
 		func(t *testing.T) {
@@ -356,6 +906,80 @@ func TestFunc_Aggregation(t *testing.T) {
 		},
 	)
 
+	t.Run(
+		"SetWindowFields",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$setWindowFields": bson.M{
+						"partitionBy": "$state",
+						"sortBy": bson.D{
+							{Key: "orderDate", Value: 1},
+						},
+						"output": bson.M{
+							"cumulativeQuantityForState": bson.M{
+								"$sum": "$quantity",
+								"window": bson.M{
+									"documents": bson.A{"unbounded", "current"},
+								},
+							},
+							"recentOrders": bson.M{
+								"$push": "$orderDate",
+								"window": bson.M{
+									"range": bson.A{-10, 0},
+									"unit":  "month",
+								},
+							},
+							"rank": bson.M{
+								"$rank": bson.M{},
+							},
+							"expMovingAvg": bson.M{
+								"$expMovingAvg": bson.M{
+									"input": "$price",
+									"N":     2,
+								},
+							},
+						},
+					},
+				},
+				aggregation.SetWindowFields(
+					aggregation.SetWindowFieldsArg().
+						PartitionBy("$state").
+						SortBy(sort.SortArg("orderDate", sort.ASC())).
+						Output(
+							"cumulativeQuantityForState",
+							op.Sum("$quantity"),
+							aggregation.WindowArg().
+								Documents(aggregation.WindowUnbounded, aggregation.WindowCurrent),
+						).
+						Output(
+							"recentOrders",
+							op.Push("$orderDate"),
+							aggregation.WindowArg().
+								Range(-10, 0).
+								Unit(op.UnitMonth),
+						).
+						Output("rank", op.Rank()).
+						Output("expMovingAvg", op.ExpMovingAvgN("$price", 2)),
+				),
+			)
+
+			require.Panics(
+				t,
+				func() {
+					aggregation.SetWindowFieldsArg().
+						Output(
+							"total",
+							op.Sum("$quantity"),
+							aggregation.WindowArg().Documents(aggregation.WindowUnbounded, aggregation.WindowCurrent),
+							aggregation.WindowArg().Range(-10, 0),
+						)
+				},
+			)
+		},
+	)
+
 	t.Run(
 		"Unwind",
 		func(t *testing.T) {
@@ -0,0 +1,405 @@
+package bsonfieldgetter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0B1t322/MongoBuilder/operators/query"
+	"github.com/0B1t322/MongoBuilder/operators/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var specialTypes = map[reflect.Type]types.Type{
+	reflect.TypeOf(time.Time{}):              types.Date,
+	reflect.TypeOf(primitive.DateTime(0)):    types.Date,
+	reflect.TypeOf(primitive.ObjectID{}):     types.ObjectId,
+	reflect.TypeOf(primitive.Decimal128{}):   types.Decimal128,
+	reflect.TypeOf(primitive.Binary{}):       types.BinaryData,
+	reflect.TypeOf([]byte{}):                 types.BinaryData,
+	reflect.TypeOf(primitive.Timestamp{}):    types.Timestamp,
+	reflect.TypeOf(primitive.Regex{}):        types.Regex,
+	reflect.TypeOf(primitive.JavaScript("")): types.JavaScript,
+	reflect.TypeOf(primitive.Symbol("")):     types.Symbol,
+	reflect.TypeOf(primitive.MinKey{}):       types.MinKey,
+	reflect.TypeOf(primitive.MaxKey{}):       types.MaxKey,
+	reflect.TypeOf(primitive.Null{}):         types.Null,
+	reflect.TypeOf(primitive.Undefined{}):    types.Undefined,
+	reflect.TypeOf(bson.D{}):                 types.Object,
+	reflect.TypeOf(bson.Raw{}):               types.Object,
+}
+
+type bsonTag struct {
+	name      string
+	omitempty bool
+	minsize   bool
+	inline    bool
+}
+
+func parseBsonTag(tag string) bsonTag {
+	splited := strings.Split(tag, ",")
+	t := bsonTag{name: splited[0]}
+	for _, opt := range splited[1:] {
+		switch opt {
+		case "omitempty":
+			t.omitempty = true
+		case "minsize":
+			t.minsize = true
+		case "inline":
+			t.inline = true
+		}
+	}
+	return t
+}
+
+type jsonSchemaWalker struct {
+	// struct types that are being walked, used to stop on recursive types
+	visiting map[reflect.Type]bool
+}
+
+/*
+Build a $jsonSchema validator from model struct fields with bson tags.
+
+	Go kinds are mapped to bsonType, time.Time and primitive types are mapped to their bson types.
+	Fields with pointer type or omitempty are optional, others are required.
+	Pointers, slices (including []byte) and maps also accept null.
+	Fields without name in bson tag, e.g. bson:",omitempty", use lowercased field name like the driver.
+	Slices and arrays are mapped to array with items schema.
+	Nested structs are mapped to nested object schema, inline structs are merged to the parent.
+
+Extra constraints can be set with jsonschema tag:
+
+	Age int `bson:"age" jsonschema:"minimum=0,maximum=150"`
+
+Supported keys: required, optional, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+minLength, maxLength, minItems, maxItems, uniqueItems, minProperties, maxProperties,
+pattern, enum (values separated by |), title, description.
+Values that contain commas must be quoted with single quotes, e.g. pattern='^[0-9]{1,3}$'.
+Enum of slice and array fields constrains their items,
+enum of pointer and map fields also accepts null.
+
+Use query.JsonSchema to create a validator from the returned schema.
+Panic if model is not a struct or jsonschema tag is invalid.
+*/
+func JsonSchema(model interface{}) query.JsonSchemaArger {
+	t := reflect.TypeOf(model)
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+
+	if t.Kind() != reflect.Struct {
+		panic("model must be a struct")
+	}
+
+	w := &jsonSchemaWalker{visiting: make(map[reflect.Type]bool)}
+	return w.objectSchema(t)
+}
+
+func (w *jsonSchemaWalker) objectSchema(t reflect.Type) query.JsonSchemaArger {
+	schema := query.JsonSchemaArg().BsonType(types.Object)
+	if w.visiting[t] {
+		return schema
+	}
+
+	w.visiting[t] = true
+	defer delete(w.visiting, t)
+
+	w.initFields(schema, t)
+	return schema
+}
+
+func (w *jsonSchemaWalker) initFields(schema query.JsonSchemaArger, t reflect.Type) {
+	numsField := t.NumField()
+	for i := 0; i < numsField; i++ {
+		w.initField(schema, t.Field(i))
+	}
+}
+
+func (w *jsonSchemaWalker) initField(schema query.JsonSchemaArger, field reflect.StructField) {
+	rawTag := field.Tag.Get("bson")
+	if rawTag == "-" || rawTag == "" {
+		return
+	}
+
+	tag := parseBsonTag(rawTag)
+	if tag.name == "" {
+		tag.name = strings.ToLower(field.Name)
+	}
+
+	if tag.inline {
+		t := field.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			w.initFields(schema, t)
+			return
+		}
+	}
+
+	fieldSchema := w.typeSchema(field.Type, tag)
+	required := !tag.omitempty && field.Type.Kind() != reflect.Ptr
+
+	if rawConstraints, ok := field.Tag.Lookup("jsonschema"); ok {
+		required = w.applyConstraints(fieldSchema, field, rawConstraints, required)
+	}
+
+	schema.Property(tag.name, fieldSchema)
+	if required {
+		schema.Required(tag.name)
+	}
+}
+
+func (w *jsonSchemaWalker) typeSchema(t reflect.Type, tag bsonTag) query.JsonSchemaArger {
+	schema, bsonTypes := w.typeSchemaWithTypes(t, tag)
+	if len(bsonTypes) > 0 {
+		schema.BsonType(bsonTypes...)
+	}
+	return schema
+}
+
+func (w *jsonSchemaWalker) typeSchemaWithTypes(t reflect.Type, tag bsonTag) (query.JsonSchemaArger, []types.Type) {
+	if bsonType, ok := specialTypes[t]; ok {
+		if t.Kind() == reflect.Slice {
+			return query.JsonSchemaArg(), []types.Type{bsonType, types.Null}
+		}
+		return query.JsonSchemaArg(), []types.Type{bsonType}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema, bsonTypes := w.typeSchemaWithTypes(t.Elem(), tag)
+		if len(bsonTypes) == 0 {
+			return schema, nil
+		}
+		return schema, append(bsonTypes, types.Null)
+	case reflect.Bool:
+		return query.JsonSchemaArg(), []types.Type{types.Boolean}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return query.JsonSchemaArg(), []types.Type{types.Int32}
+	case reflect.Int:
+		return query.JsonSchemaArg(), []types.Type{types.Int32, types.Int64}
+	case reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		if tag.minsize && t.Kind() != reflect.Uint64 {
+			return query.JsonSchemaArg(), []types.Type{types.Int32, types.Int64}
+		}
+		return query.JsonSchemaArg(), []types.Type{types.Int64}
+	case reflect.Float32, reflect.Float64:
+		return query.JsonSchemaArg(), []types.Type{types.Dobule}
+	case reflect.String:
+		return query.JsonSchemaArg(), []types.Type{types.String}
+	case reflect.Slice, reflect.Array:
+		schema := query.JsonSchemaArg()
+		if t.Elem().Kind() != reflect.Interface {
+			schema.Items(w.typeSchema(t.Elem(), bsonTag{}))
+		}
+		if t.Kind() == reflect.Slice {
+			return schema, []types.Type{types.Array, types.Null}
+		}
+		return schema, []types.Type{types.Array}
+	case reflect.Map:
+		schema := query.JsonSchemaArg()
+		if t.Elem().Kind() != reflect.Interface {
+			schema.AdditionalPropertiesSchema(w.typeSchema(t.Elem(), bsonTag{}))
+		}
+		return schema, []types.Type{types.Object, types.Null}
+	case reflect.Struct:
+		return w.objectSchema(t), []types.Type{types.Object}
+	}
+
+	// interface and other kinds can be any type
+	return query.JsonSchemaArg(), nil
+}
+
+func (w *jsonSchemaWalker) applyConstraints(
+	schema query.JsonSchemaArger,
+	field reflect.StructField,
+	rawConstraints string,
+	required bool,
+) bool {
+	for _, constraint := range splitConstraints(rawConstraints) {
+		if constraint == "" {
+			continue
+		}
+
+		splited := strings.SplitN(constraint, "=", 2)
+		key, value := splited[0], ""
+		if len(splited) > 1 {
+			value = unquote(splited[1])
+		}
+
+		var err error
+		switch key {
+		case "required":
+			required = true
+		case "optional":
+			required = false
+		case "minimum":
+			var n interface{}
+			n, err = parseNumber(value)
+			schema.Minimum(n)
+		case "maximum":
+			var n interface{}
+			n, err = parseNumber(value)
+			schema.Maximum(n)
+		case "exclusiveMinimum":
+			schema.ExclusiveMinimum(true)
+		case "exclusiveMaximum":
+			schema.ExclusiveMaximum(true)
+		case "minLength":
+			err = parseCount(value, schema.MinLength)
+		case "maxLength":
+			err = parseCount(value, schema.MaxLength)
+		case "minItems":
+			err = parseCount(value, schema.MinItems)
+		case "maxItems":
+			err = parseCount(value, schema.MaxItems)
+		case "uniqueItems":
+			schema.UniqueItems(true)
+		case "minProperties":
+			err = parseCount(value, schema.MinProperties)
+		case "maxProperties":
+			err = parseCount(value, schema.MaxProperties)
+		case "pattern":
+			schema.Pattern(value)
+		case "enum":
+			var values []interface{}
+			values, err = parseEnum(value, field.Type)
+			if elem, ok := listElem(field.Type); ok {
+				schema.Items(w.typeSchema(elem, bsonTag{}).Enum(values...))
+				break
+			}
+			if isNullable(field.Type) {
+				values = append(values, nil)
+			}
+			schema.Enum(values...)
+		case "title":
+			schema.Title(value)
+		case "description":
+			schema.Description(value)
+		default:
+			err = fmt.Errorf("unknown key %q, quote values that contain commas", key)
+		}
+
+		if err != nil {
+			panic(fmt.Sprintf("invalid jsonschema tag on field %s: %v", field.Name, err))
+		}
+	}
+	return required
+}
+
+/*
+Split constraints by commas, commas in quoted values are kept:
+
+	pattern='^[0-9]{1,3}$',description=code
+
+Quoted value ends with a quote followed by comma or the end of tag.
+*/
+func splitConstraints(rawConstraints string) (constraints []string) {
+	start, quoted := 0, false
+	for i := 0; i < len(rawConstraints); i++ {
+		switch {
+		case !quoted && rawConstraints[i] == '\'' && i > 0 && rawConstraints[i-1] == '=':
+			quoted = true
+		case quoted && rawConstraints[i] == '\'' && (i == len(rawConstraints)-1 || rawConstraints[i+1] == ','):
+			quoted = false
+		case !quoted && rawConstraints[i] == ',':
+			constraints = append(constraints, rawConstraints[start:i])
+			start = i + 1
+		}
+	}
+	return append(constraints, rawConstraints[start:])
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Pointers, slices and maps are stored as null when they are nil
+func isNullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// Return element type of slice and array, special types like []byte are not lists
+func listElem(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if _, special := specialTypes[t]; special {
+		return nil, false
+	}
+
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		return t.Elem(), true
+	}
+	return nil, false
+}
+
+func parseNumber(value string) (interface{}, error) {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseCount(value string, set func(int64) query.JsonSchemaArger) error {
+	count, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	set(count)
+	return nil
+}
+
+func parseEnum(value string, t reflect.Type) ([]interface{}, error) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	var values []interface{}
+	for _, v := range strings.Split(value, "|") {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, i)
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, f)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, b)
+		default:
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
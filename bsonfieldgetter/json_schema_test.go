@@ -0,0 +1,141 @@
+package bsonfieldgetter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0B1t322/MongoBuilder/bsonfieldgetter"
+	"github.com/0B1t322/MongoBuilder/operators/query"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type schemaAddress struct {
+	City string `bson:"city" jsonschema:"minLength=1"`
+	Zip  *int32 `bson:"zip"`
+}
+
+type schemaModel struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name" jsonschema:"pattern=^[a-z]+$,description=user name"`
+	Age       int                `bson:"age" jsonschema:"minimum=0,maximum=150"`
+	Score     float64            `bson:"score,omitempty"`
+	Status    string             `bson:"status" jsonschema:"enum=active|banned"`
+	Role      *string            `bson:"role" jsonschema:"enum=admin|user"`
+	Code      string             `bson:"code" jsonschema:"pattern='^[0-9]{1,3}$',description='numeric code, 1-3 digits'"`
+	Tags      []string           `bson:"tags" jsonschema:"uniqueItems"`
+	Labels    []string           `bson:"labels,omitempty" jsonschema:"enum=new|hot"`
+	Avatar    []byte             `bson:"avatar,omitempty"`
+	Nickname  string             `bson:",omitempty"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	Address   schemaAddress      `bson:"address"`
+	Manager   *schemaModel       `bson:"manager"`
+	Hidden    string             `bson:"-"`
+	Untagged  string
+
+	Inline struct {
+		Version int64 `bson:"version"`
+	} `bson:",inline"`
+}
+
+func TestFunc_JsonSchema(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"$jsonSchema": bson.M{
+				"bsonType": "object",
+				"required": bson.A{"name", "age", "status", "code", "tags", "createdAt", "address", "version"},
+				"properties": bson.M{
+					"_id": bson.M{
+						"bsonType": "objectId",
+					},
+					"name": bson.M{
+						"bsonType":    "string",
+						"pattern":     "^[a-z]+$",
+						"description": "user name",
+					},
+					"age": bson.M{
+						"bsonType": bson.A{"int", "long"},
+						"minimum":  int64(0),
+						"maximum":  int64(150),
+					},
+					"score": bson.M{
+						"bsonType": "double",
+					},
+					"status": bson.M{
+						"bsonType": "string",
+						"enum":     bson.A{"active", "banned"},
+					},
+					"role": bson.M{
+						"bsonType": bson.A{"string", "null"},
+						"enum":     bson.A{"admin", "user", nil},
+					},
+					"code": bson.M{
+						"bsonType":    "string",
+						"pattern":     "^[0-9]{1,3}$",
+						"description": "numeric code, 1-3 digits",
+					},
+					"tags": bson.M{
+						"bsonType": bson.A{"array", "null"},
+						"items": bson.M{
+							"bsonType": "string",
+						},
+						"uniqueItems": true,
+					},
+					"labels": bson.M{
+						"bsonType": bson.A{"array", "null"},
+						"items": bson.M{
+							"bsonType": "string",
+							"enum":     bson.A{"new", "hot"},
+						},
+					},
+					"avatar": bson.M{
+						"bsonType": bson.A{"binData", "null"},
+					},
+					"nickname": bson.M{
+						"bsonType": "string",
+					},
+					"createdAt": bson.M{
+						"bsonType": "date",
+					},
+					"address": bson.M{
+						"bsonType": "object",
+						"required": bson.A{"city"},
+						"properties": bson.M{
+							"city": bson.M{
+								"bsonType":  "string",
+								"minLength": int64(1),
+							},
+							"zip": bson.M{
+								"bsonType": bson.A{"int", "null"},
+							},
+						},
+					},
+					"manager": bson.M{
+						"bsonType": bson.A{"object", "null"},
+					},
+					"version": bson.M{
+						"bsonType": "long",
+					},
+				},
+			},
+		},
+		query.JsonSchema(
+			bsonfieldgetter.JsonSchema(schemaModel{}),
+		),
+	)
+}
+
+func TestFunc_JsonSchemaInvalidTag(t *testing.T) {
+	require.Panics(
+		t,
+		func() {
+			bsonfieldgetter.JsonSchema(
+				struct {
+					Age int `bson:"age" jsonschema:"minimum=zero"`
+				}{},
+			)
+		},
+	)
+}
@@ -1,6 +1,8 @@
 package object
 
 import (
+	"sort"
+
 	"github.com/0B1t322/MongoBuilder/utils"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -9,29 +11,55 @@ type ObjectBuilder interface {
 	// if value is ObjectBuilder will build the object
 	AddField(field string, value interface{}) ObjectBuilder
 	Build() bson.M
+	// Build the object as bson.D, fields are in order they were added
+	BuildOrdered() bson.D
 	Merge(other ObjectBuilder) ObjectBuilder
 	MergeBson(other bson.M) ObjectBuilder
+	// Merge with bson.D keeping the order of fields
+	MergeBsonD(other bson.D) ObjectBuilder
 }
 
 type objectBulder struct {
-	object bson.M
+	object  bson.M
+	ordered bson.D
 }
 
 func (o *objectBulder) AddField(field string, value interface{}) ObjectBuilder {
 	if v, ok := value.(ObjectBuilder); ok {
 		o.object[field] = v.Build()
+		o.setOrdered(field, v.BuildOrdered())
 	} else {
 		o.object[field] = value
+		o.setOrdered(field, value)
 	}
 	return o
 }
 
+func (o *objectBulder) setOrdered(field string, value interface{}) {
+	for i := range o.ordered {
+		if o.ordered[i].Key == field {
+			o.ordered[i].Value = value
+			return
+		}
+	}
+	o.ordered = append(o.ordered, bson.E{Key: field, Value: value})
+}
+
 func (o *objectBulder) Merge(other ObjectBuilder) ObjectBuilder {
-	return o.MergeBson(other.Build())
+	o.object = utils.MergeBsonM(o.object, other.Build())
+	o.ordered = utils.MergeBsonD(o.ordered, other.BuildOrdered())
+	return o
 }
 
 func (o *objectBulder) MergeBson(other bson.M) ObjectBuilder {
 	o.object = utils.MergeBsonM(o.object, other)
+	o.ordered = utils.MergeBsonD(o.ordered, sortedBsonD(other))
+	return o
+}
+
+func (o *objectBulder) MergeBsonD(other bson.D) ObjectBuilder {
+	o.object = utils.MergeBsonM(o.object, other.Map())
+	o.ordered = utils.MergeBsonD(o.ordered, other)
 	return o
 }
 
@@ -39,6 +67,25 @@ func (o *objectBulder) Build() bson.M {
 	return o.object
 }
 
+func (o *objectBulder) BuildOrdered() bson.D {
+	return o.ordered
+}
+
+// bson.M have no order so keys are sorted to get the same result every time
+func sortedBsonD(m bson.M) bson.D {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	d := bson.D{}
+	for _, k := range keys {
+		d = append(d, bson.E{Key: k, Value: m[k]})
+	}
+	return d
+}
+
 func Object() ObjectBuilder {
-	return &objectBulder{object: bson.M{}}
+	return &objectBulder{object: bson.M{}, ordered: bson.D{}}
 }
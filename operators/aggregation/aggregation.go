@@ -1,10 +1,17 @@
 package aggregation
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/0B1t322/MongoBuilder/operators/options"
+	"github.com/0B1t322/MongoBuilder/operators/sort"
 	"github.com/0B1t322/MongoBuilder/operators/types"
 	"github.com/0B1t322/MongoBuilder/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // if cond is true return defaultValue
@@ -256,6 +263,242 @@ func SingleTrunc(number interface{}) bson.M {
 	}
 }
 
+// Trigonometry Expression Operators
+
+/*
+Returns the sine of a value that is measured in radians.
+
+$sin has the following syntax:
+	{ $sin: <expression> }
+*/
+func Sin(expression interface{}) bson.M {
+	return bson.M{
+		"$sin": expression,
+	}
+}
+
+/*
+Returns the cosine of a value that is measured in radians.
+
+$cos has the following syntax:
+	{ $cos: <expression> }
+*/
+func Cos(expression interface{}) bson.M {
+	return bson.M{
+		"$cos": expression,
+	}
+}
+
+/*
+Returns the tangent of a value that is measured in radians.
+
+$tan has the following syntax:
+	{ $tan: <expression> }
+*/
+func Tan(expression interface{}) bson.M {
+	return bson.M{
+		"$tan": expression,
+	}
+}
+
+/*
+Returns the inverse sine (arc sine) of a value in radians.
+The <expression> must resolve to a number between -1 and 1.
+
+$asin has the following syntax:
+	{ $asin: <expression> }
+*/
+func Asin(expression interface{}) bson.M {
+	return bson.M{
+		"$asin": expression,
+	}
+}
+
+/*
+Returns the inverse cosine (arc cosine) of a value in radians.
+The <expression> must resolve to a number between -1 and 1.
+
+$acos has the following syntax:
+	{ $acos: <expression> }
+*/
+func Acos(expression interface{}) bson.M {
+	return bson.M{
+		"$acos": expression,
+	}
+}
+
+/*
+Returns the inverse tangent (arc tangent) of a value in radians.
+
+$atan has the following syntax:
+	{ $atan: <expression> }
+*/
+func Atan(expression interface{}) bson.M {
+	return bson.M{
+		"$atan": expression,
+	}
+}
+
+/*
+Returns the inverse tangent (arc tangent) of y / x in radians, where y and x are the first and second values passed to the expression respectively.
+
+$atan2 has the following syntax:
+	{ $atan2: [ <expression 1>, <expression 2> ] }
+*/
+func Atan2(y, x interface{}) bson.M {
+	return bson.M{
+		"$atan2": bson.A{y, x},
+	}
+}
+
+/*
+Returns the hyperbolic sine of a value that is measured in radians.
+
+$sinh has the following syntax:
+	{ $sinh: <expression> }
+*/
+func Sinh(expression interface{}) bson.M {
+	return bson.M{
+		"$sinh": expression,
+	}
+}
+
+/*
+Returns the hyperbolic cosine of a value that is measured in radians.
+
+$cosh has the following syntax:
+	{ $cosh: <expression> }
+*/
+func Cosh(expression interface{}) bson.M {
+	return bson.M{
+		"$cosh": expression,
+	}
+}
+
+/*
+Returns the hyperbolic tangent of a value that is measured in radians.
+
+$tanh has the following syntax:
+	{ $tanh: <expression> }
+*/
+func Tanh(expression interface{}) bson.M {
+	return bson.M{
+		"$tanh": expression,
+	}
+}
+
+/*
+Returns the inverse hyperbolic sine (hyperbolic arc sine) of a value in radians.
+
+$asinh has the following syntax:
+	{ $asinh: <expression> }
+*/
+func Asinh(expression interface{}) bson.M {
+	return bson.M{
+		"$asinh": expression,
+	}
+}
+
+/*
+Returns the inverse hyperbolic cosine (hyperbolic arc cosine) of a value in radians.
+The <expression> must resolve to a number between 1 and +Infinity.
+
+$acosh has the following syntax:
+	{ $acosh: <expression> }
+*/
+func Acosh(expression interface{}) bson.M {
+	return bson.M{
+		"$acosh": expression,
+	}
+}
+
+/*
+Returns the inverse hyperbolic tangent (hyperbolic arc tangent) of a value in radians.
+The <expression> must resolve to a number between -1 and 1.
+
+$atanh has the following syntax:
+	{ $atanh: <expression> }
+*/
+func Atanh(expression interface{}) bson.M {
+	return bson.M{
+		"$atanh": expression,
+	}
+}
+
+/*
+Converts an input value measured in degrees to radians.
+
+$degreesToRadians has the following syntax:
+	{ $degreesToRadians: <expression> }
+*/
+func DegreesToRadians(expression interface{}) bson.M {
+	return bson.M{
+		"$degreesToRadians": expression,
+	}
+}
+
+/*
+Converts an input value measured in radians to degrees.
+
+$radiansToDegrees has the following syntax:
+	{ $radiansToDegrees: <expression> }
+*/
+func RadiansToDegrees(expression interface{}) bson.M {
+	return bson.M{
+		"$radiansToDegrees": expression,
+	}
+}
+
+// Bitwise Operators
+
+/*
+Returns the result of a bitwise and operation on an array of int or long values.
+
+$bitAnd has the following syntax:
+	{ $bitAnd: [ <expression1>, <expression2>, ... ] }
+*/
+func BitAnd(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$bitAnd": bson.A(expressions),
+	}
+}
+
+/*
+Returns the result of a bitwise or operation on an array of int or long values.
+
+$bitOr has the following syntax:
+	{ $bitOr: [ <expression1>, <expression2>, ... ] }
+*/
+func BitOr(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$bitOr": bson.A(expressions),
+	}
+}
+
+/*
+Returns the result of a bitwise xor (exclusive or) operation on an array of int and long values.
+
+$bitXor has the following syntax:
+	{ $bitXor: [ <expression1>, <expression2>, ... ] }
+*/
+func BitXor(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$bitXor": bson.A(expressions),
+	}
+}
+
+/*
+Returns the result of a bitwise not operation on a single int or long value.
+
+$bitNot has the following syntax:
+	{ $bitNot: <expression> }
+*/
+func BitNot(expression interface{}) bson.M {
+	return bson.M{
+		"$bitNot": expression,
+	}
+}
+
 // Array Expression Operators
 
 /*
@@ -319,19 +562,27 @@ input: An expression that resolves to an array.
 as: A name for the variable that represents each individual element of the input array. If no name is specified, the variable name defaults to this.
 
 cond: An expression that resolves to a boolean value used to determine if an element should be included in the output array. The expression references each element of the input array individually with the variable name specified in as.
+
+limit: Optional. A number expression that restricts the number of matching array elements that $filter returns, only the first is used.
 */
 func Filter(
 	input interface{},
 	// if as equal "" set to "this"
 	as string,
 	cond interface{},
+	limit ...interface{},
 ) bson.M {
+	b := bson.M{
+		"input": input,
+		"as":    condDefaultOrValue(as, as == "", "this"),
+		"cond":  cond,
+	}
+	if len(limit) > 0 {
+		b["limit"] = limit[0]
+	}
+
 	return bson.M{
-		"$filter": bson.M{
-			"input": input,
-			"as":    condDefaultOrValue(as, as == "", "this"),
-			"cond":  cond,
-		},
+		"$filter": b,
 	}
 }
 
@@ -375,12 +626,29 @@ type indexOfArrayOptionalParams struct {
 
 func (i indexOfArrayOptionalParams) merge(is ...IndexOfArrayOptionalParams) indexOfArrayOptionalParams {
 	for _, ip := range is {
-		i.start = ip.getStart()
-		i.end = ip.getEnd()
+		if start := ip.getStart(); start != nil {
+			i.start = start
+		}
+		if end := ip.getEnd(); end != nil {
+			i.end = end
+		}
 	}
 	return i
 }
 
+// Return [ <first>, <second>, <start>, <end> ], start is 0 if only end is set
+func (i indexOfArrayOptionalParams) format(first, second interface{}) bson.A {
+	a := bson.A{first, second}
+	if i.start != nil || i.end != nil {
+		a = append(a, defaultOrValue(i.start, 0))
+	}
+
+	if i.end != nil {
+		a = append(a, i.end)
+	}
+	return a
+}
+
 func (i indexOfArrayOptionalParams) getStart() interface{} {
 	return i.start
 }
@@ -407,6 +675,11 @@ func (i indexOfArrayOptionalParams) SetEnd(end interface{}) IndexOfArrayOptional
 	return i.setEnd(end)
 }
 
+// Optional params of IndexOfArray, IndexOfBytes and IndexOfCP
+func IndexOfOptionalParamsArgs() IndexOfArrayOptionalParams {
+	return indexOfArrayOptionalParams{}
+}
+
 /*
 Searches an array for an occurrence of a specified value and returns the array index (zero-based) of the first occurrence. If the value is not found, returns -1.
 $indexOfArray has the following operator expression syntax:
@@ -417,20 +690,10 @@ func IndexOfArray(
 	searchExpression interface{},
 	optionalParams ...IndexOfArrayOptionalParams,
 ) bson.M {
-	a := bson.A{arrayExpression, searchExpression}
-	opt := indexOfArrayOptionalParams{}.
-		merge(optionalParams...)
-
-	if opt.start != nil {
-		a = append(a, opt.start)
-	}
-
-	if opt.end != nil {
-		a = append(a, opt.end)
-	}
-
 	return bson.M{
-		"$indexOfArray": a,
+		"$indexOfArray": indexOfArrayOptionalParams{}.
+			merge(optionalParams...).
+			format(arrayExpression, searchExpression),
 	}
 }
 
@@ -551,6 +814,50 @@ func Reduce(
 	}
 }
 
+/*
+Sorts an array of documents based on fields.
+
+	{
+		$sortArray: {
+			input: <array>,
+			sortBy: { <field1>: <sort order>, <field2>: <sort order> ... }
+		}
+	}
+*/
+func SortArray(
+	input interface{},
+	sortBy ...sort.SortArger,
+) bson.M {
+	return bson.M{
+		"$sortArray": bson.M{
+			"input":  input,
+			"sortBy": sort.Spec(sortBy...),
+		},
+	}
+}
+
+/*
+Sorts an array of values or sorts documents by the whole document.
+
+	{
+		$sortArray: {
+			input: <array>,
+			sortBy: <sort order>
+		}
+	}
+*/
+func SortArrayValues(
+	input interface{},
+	order sort.SortOrder,
+) bson.M {
+	return bson.M{
+		"$sortArray": bson.M{
+			"input":  input,
+			"sortBy": sort.OrderValue(order),
+		},
+	}
+}
+
 /*
 Accepts an array expression as an argument and returns an array with the elements in reverse order.
 
@@ -822,68 +1129,887 @@ func Switch(
 	}
 }
 
-// TODO Date Expression Operators
+// Date Expression Operators
 
-func ToDate(
-	expression interface{},
-) bson.M {
-	return bson.M{
-		"$toDate": expression,
+/*
+Timezone is an Olson Timezone Identifier (e.g. "Europe/London") or an UTC offset (e.g. "+03:00").
+Also can be an expression that resolves to them, e.g. Timezone("$tz").
+*/
+type Timezone string
+
+const TimezoneUTC Timezone = "UTC"
+
+/*
+Convert location to the timezone.
+
+Location loaded by name (time.LoadLocation) is converted to the Olson Timezone Identifier.
+time.Local, fixed zones and locations whose offsets differ from the zone of their name
+are converted to the current UTC offset of location.
+nil location is UTC.
+*/
+func TimezoneFromLocation(loc *time.Location) Timezone {
+	if loc == nil || loc == time.UTC {
+		return TimezoneUTC
+	}
+
+	now := time.Now()
+	if name := loc.String(); name != "" && name != "Local" {
+		if loaded := loadLocation(name); loaded != nil && sameOffsets(loc, loaded, now) {
+			return Timezone(name)
+		}
 	}
+
+	_, offset := now.In(loc).Zone()
+	return TimezoneOffset(time.Duration(offset) * time.Second)
 }
 
-func Literal(
-	value interface{},
-) bson.M {
-	return bson.M{
-		"$literal": value,
+// Loaded locations by name, nil if location is unknown. LoadLocation reads tzdata on every call
+var locations sync.Map
+
+func loadLocation(name string) *time.Location {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = nil
 	}
+	locations.Store(name, loc)
+	return loc
 }
 
-func MergeObjects(
-	docs ...interface{},
-) bson.M {
-	return bson.M{
-		"$mergeObjects": condDefaultOrValue(bson.A(docs), len(docs) == 1, docs[0]),
+// Compare offsets now and in the middle of winter and summer of the current year to catch DST difference
+func sameOffsets(a, b *time.Location, now time.Time) bool {
+	for _, t := range []time.Time{
+		now,
+		time.Date(now.Year(), time.January, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(now.Year(), time.July, 15, 0, 0, 0, 0, time.UTC),
+	} {
+		_, aOffset := t.In(a).Zone()
+		_, bOffset := t.In(b).Zone()
+		if aOffset != bOffset {
+			return false
+		}
 	}
+	return true
 }
 
-func SetField(
-	field string,
-	input interface{},
-	value interface{},
-) bson.M {
-	return bson.M{
-		"$setField": bson.M{
-			"field": field,
-			"input": input,
-			"value": value,
-		},
+/*
+Return UTC offset timezone in format +/-[hh]:[mm], offset is truncated to minutes.
+
+	TimezoneOffset(3*time.Hour + 30*time.Minute) // "+03:30"
+*/
+func TimezoneOffset(offset time.Duration) Timezone {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
 	}
+	minutes := int64(offset / time.Minute)
+	return Timezone(fmt.Sprintf("%s%02d:%02d", sign, minutes/60, minutes%60))
 }
 
-func AllElementsTrue(
-	expression interface{},
-) bson.M {
-	return bson.M{
-		"$allElementsTrue": bson.A{expression},
+/*
+Return the largest time unit from week to millisecond that divides the duration without remainder and amount of that units.
+Duration is truncated to milliseconds.
+
+	TimeUnitFromDuration(90 * time.Minute) // UnitMinute, 90
+	TimeUnitFromDuration(48 * time.Hour)   // UnitDay, 2
+*/
+func TimeUnitFromDuration(d time.Duration) (TimeUnit, int64) {
+	ms := int64(d / time.Millisecond)
+	units := []struct {
+		unit TimeUnit
+		ms   int64
+	}{
+		{UnitWeek, int64(7 * 24 * time.Hour / time.Millisecond)},
+		{UnitDay, int64(24 * time.Hour / time.Millisecond)},
+		{UnitHour, int64(time.Hour / time.Millisecond)},
+		{UnitMinute, int64(time.Minute / time.Millisecond)},
+		{UnitSecond, int64(time.Second / time.Millisecond)},
+	}
+	if ms != 0 {
+		for _, u := range units {
+			if ms%u.ms == 0 {
+				return u.unit, ms / u.ms
+			}
+		}
 	}
+	return UnitMillisecond, ms
 }
 
-func AnyElemetsTrue(
-	expression interface{},
-) bson.M {
-	return bson.M{
-		"$anyElementTrue": bson.A{expression},
+func weekdayIdentifier(day time.Weekday) string {
+	return strings.ToLower(day.String())
+}
+
+func dateWithTimezone(date interface{}, timezone ...Timezone) bson.M {
+	b := bson.M{
+		"date": date,
+	}
+	if len(timezone) > 0 {
+		b["timezone"] = timezone[0]
 	}
+	return b
 }
 
-func SetDifference(
-	left,
-	right interface{},
+func dateAdd(
+	operator string,
+	startDate interface{},
+	unit TimeUnit,
+	amount interface{},
+	timezone ...Timezone,
 ) bson.M {
+	b := bson.M{
+		"startDate": startDate,
+		"unit":      unit.String(),
+		"amount":    amount,
+	}
+	if len(timezone) > 0 {
+		b["timezone"] = timezone[0]
+	}
 	return bson.M{
-		"$setDifference": bson.A{left, right},
+		operator: b,
+	}
+}
+
+/*
+Increments a Date object by a specified number of time units.
+
+	{
+		$dateAdd: {
+			startDate: <Expression>,
+			unit: <Expression>,
+			amount: <Expression>,
+			timezone: <tzExpression>
+		}
+	}
+
+timezone is optional.
+*/
+func DateAdd(
+	startDate interface{},
+	unit TimeUnit,
+	amount interface{},
+	timezone ...Timezone,
+) bson.M {
+	return dateAdd("$dateAdd", startDate, unit, amount, timezone...)
+}
+
+// Work like DateAdd but unit and amount are taken from duration, see TimeUnitFromDuration
+func DateAddDuration(
+	startDate interface{},
+	d time.Duration,
+	timezone ...Timezone,
+) bson.M {
+	unit, amount := TimeUnitFromDuration(d)
+	return DateAdd(startDate, unit, amount, timezone...)
+}
+
+/*
+Decrements a Date object by a specified number of time units.
+
+	{
+		$dateSubtract: {
+			startDate: <Expression>,
+			unit: <Expression>,
+			amount: <Expression>,
+			timezone: <tzExpression>
+		}
+	}
+
+timezone is optional.
+*/
+func DateSubtract(
+	startDate interface{},
+	unit TimeUnit,
+	amount interface{},
+	timezone ...Timezone,
+) bson.M {
+	return dateAdd("$dateSubtract", startDate, unit, amount, timezone...)
+}
+
+// Work like DateSubtract but unit and amount are taken from duration, see TimeUnitFromDuration
+func DateSubtractDuration(
+	startDate interface{},
+	d time.Duration,
+	timezone ...Timezone,
+) bson.M {
+	unit, amount := TimeUnitFromDuration(d)
+	return DateSubtract(startDate, unit, amount, timezone...)
+}
+
+type DateOptionalsArger interface {
+	dateOptionals() dateOptionals
+	Timezone(timezone Timezone) DateOptionalsArger
+	// Used when unit is week, default is sunday
+	StartOfWeek(day time.Weekday) DateOptionalsArger
+	// Used only by DateTrunc
+	BinSize(binSize interface{}) DateOptionalsArger
+}
+
+type dateOptionals struct {
+	timezone    *Timezone
+	startOfWeek *time.Weekday
+	binSize     interface{}
+}
+
+func (d dateOptionals) dateOptionals() dateOptionals {
+	return d
+}
+
+func (d dateOptionals) Timezone(timezone Timezone) DateOptionalsArger {
+	d.timezone = &timezone
+	return d
+}
+
+func (d dateOptionals) StartOfWeek(day time.Weekday) DateOptionalsArger {
+	d.startOfWeek = &day
+	return d
+}
+
+func (d dateOptionals) BinSize(binSize interface{}) DateOptionalsArger {
+	d.binSize = binSize
+	return d
+}
+
+func (d dateOptionals) merge(opts ...DateOptionalsArger) dateOptionals {
+	for _, opt := range opts {
+		o := opt.dateOptionals()
+		if o.timezone != nil {
+			d.timezone = o.timezone
+		}
+		if o.startOfWeek != nil {
+			d.startOfWeek = o.startOfWeek
+		}
+		if o.binSize != nil {
+			d.binSize = o.binSize
+		}
+	}
+	return d
+}
+
+func (d dateOptionals) format() bson.M {
+	optionals := bson.M{}
+	if d.timezone != nil {
+		optionals["timezone"] = *d.timezone
+	}
+	if d.startOfWeek != nil {
+		optionals["startOfWeek"] = weekdayIdentifier(*d.startOfWeek)
+	}
+	if d.binSize != nil {
+		optionals["binSize"] = d.binSize
+	}
+	return optionals
+}
+
+func DateOptionalsArg() DateOptionalsArger {
+	return dateOptionals{}
+}
+
+/*
+Returns the difference between two dates.
+
+	{
+		$dateDiff: {
+			startDate: <Expression>,
+			endDate: <Expression>,
+			unit: <Expression>,
+			timezone: <tzExpression>,
+			startOfWeek: <String>
+		}
+	}
+
+timezone and startOfWeek are optional, binSize is ignored.
+*/
+func DateDiff(
+	startDate interface{},
+	endDate interface{},
+	unit TimeUnit,
+	opts ...DateOptionalsArger,
+) bson.M {
+	optionals := dateOptionals{}.merge(opts...)
+	optionals.binSize = nil
+
+	return bson.M{
+		"$dateDiff": utils.MergeBsonM(
+			bson.M{
+				"startDate": startDate,
+				"endDate":   endDate,
+				"unit":      unit.String(),
+			},
+			optionals.format(),
+		),
+	}
+}
+
+/*
+Truncates a date.
+
+	{
+		$dateTrunc: {
+			date: <Expression>,
+			unit: <Expression>,
+			binSize: <Expression>,
+			timezone: <tzExpression>,
+			startOfWeek: <Expression>
+		}
+	}
+
+binSize, timezone and startOfWeek are optional.
+*/
+func DateTrunc(
+	date interface{},
+	unit TimeUnit,
+	opts ...DateOptionalsArger,
+) bson.M {
+	return bson.M{
+		"$dateTrunc": utils.MergeBsonM(
+			bson.M{
+				"date": date,
+				"unit": unit.String(),
+			},
+			dateOptionals{}.merge(opts...).format(),
+		),
+	}
+}
+
+// Don't use calendar and iso week date parts together
+type DateFromPartsArger interface {
+	formatDateFromPartsArg() bson.M
+	Year(year interface{}) DateFromPartsArger
+	Month(month interface{}) DateFromPartsArger
+	Day(day interface{}) DateFromPartsArger
+	IsoWeekYear(year interface{}) DateFromPartsArger
+	IsoWeek(week interface{}) DateFromPartsArger
+	IsoDayOfWeek(day interface{}) DateFromPartsArger
+	Hour(hour interface{}) DateFromPartsArger
+	Minute(minute interface{}) DateFromPartsArger
+	Second(second interface{}) DateFromPartsArger
+	Millisecond(millisecond interface{}) DateFromPartsArger
+	Timezone(timezone Timezone) DateFromPartsArger
+}
+
+type dateFromPartsArg struct {
+	parts bson.D
+}
+
+func (d dateFromPartsArg) formatDateFromPartsArg() bson.M {
+	return d.parts.Map()
+}
+
+func (d dateFromPartsArg) set(part string, value interface{}) DateFromPartsArger {
+	parts := make(bson.D, 0, len(d.parts)+1)
+	for _, e := range d.parts {
+		if e.Key != part {
+			parts = append(parts, e)
+		}
+	}
+	d.parts = append(parts, bson.E{Key: part, Value: value})
+	return d
+}
+
+func (d dateFromPartsArg) Year(year interface{}) DateFromPartsArger {
+	return d.set("year", year)
+}
+
+func (d dateFromPartsArg) Month(month interface{}) DateFromPartsArger {
+	return d.set("month", month)
+}
+
+func (d dateFromPartsArg) Day(day interface{}) DateFromPartsArger {
+	return d.set("day", day)
+}
+
+func (d dateFromPartsArg) IsoWeekYear(year interface{}) DateFromPartsArger {
+	return d.set("isoWeekYear", year)
+}
+
+func (d dateFromPartsArg) IsoWeek(week interface{}) DateFromPartsArger {
+	return d.set("isoWeek", week)
+}
+
+func (d dateFromPartsArg) IsoDayOfWeek(day interface{}) DateFromPartsArger {
+	return d.set("isoDayOfWeek", day)
+}
+
+func (d dateFromPartsArg) Hour(hour interface{}) DateFromPartsArger {
+	return d.set("hour", hour)
+}
+
+func (d dateFromPartsArg) Minute(minute interface{}) DateFromPartsArger {
+	return d.set("minute", minute)
+}
+
+func (d dateFromPartsArg) Second(second interface{}) DateFromPartsArger {
+	return d.set("second", second)
+}
+
+func (d dateFromPartsArg) Millisecond(millisecond interface{}) DateFromPartsArger {
+	return d.set("millisecond", millisecond)
+}
+
+func (d dateFromPartsArg) Timezone(timezone Timezone) DateFromPartsArger {
+	return d.set("timezone", timezone)
+}
+
+func DateFromPartsArg() DateFromPartsArger {
+	return dateFromPartsArg{}
+}
+
+/*
+Constructs and returns a Date object given the date's constituent properties.
+
+	{
+		$dateFromParts : {
+			year: <year>, month: <month>, day: <day>,
+			hour: <hour>, minute: <minute>, second: <second>,
+			millisecond: <ms>, timezone: <tzExpression>
+		}
+	}
+
+Or with ISO week date
+
+	{
+		$dateFromParts : {
+			isoWeekYear: <year>, isoWeek: <week>, isoDayOfWeek: <day>,
+			hour: <hour>, minute: <minute>, second: <second>,
+			millisecond: <ms>, timezone: <tzExpression>
+		}
+	}
+*/
+func DateFromParts(
+	arg DateFromPartsArger,
+) bson.M {
+	return bson.M{
+		"$dateFromParts": arg.formatDateFromPartsArg(),
+	}
+}
+
+/*
+Returns a document that contains the constituent parts of a given date value as individual properties.
+
+	{
+		$dateToParts: {
+			date: <dateExpression>,
+			timezone: <tzExpression>,
+			iso8601: <boolean>
+		}
+	}
+
+iso8601 is set only if it's true, timezone is optional.
+*/
+func DateToParts(
+	date interface{},
+	iso8601 bool,
+	timezone ...Timezone,
+) bson.M {
+	b := dateWithTimezone(date, timezone...)
+	if iso8601 {
+		b["iso8601"] = true
+	}
+	return bson.M{
+		"$dateToParts": b,
+	}
+}
+
+type DateFromStringArger interface {
+	formatDateFromStringArg() bson.M
+	Format(format string) DateFromStringArger
+	Timezone(timezone Timezone) DateFromStringArger
+	OnError(expression interface{}) DateFromStringArger
+	OnNull(expression interface{}) DateFromStringArger
+}
+
+type dateFromStringArg struct {
+	dateString interface{}
+	format     string
+	timezone   *Timezone
+	onError    interface{}
+	onNull     interface{}
+}
+
+func (d dateFromStringArg) formatDateFromStringArg() bson.M {
+	b := bson.M{
+		"dateString": d.dateString,
+	}
+	if d.format != "" {
+		b["format"] = d.format
+	}
+	if d.timezone != nil {
+		b["timezone"] = *d.timezone
+	}
+	if d.onError != nil {
+		b["onError"] = d.onError
+	}
+	if d.onNull != nil {
+		b["onNull"] = d.onNull
+	}
+	return b
+}
+
+func (d dateFromStringArg) Format(format string) DateFromStringArger {
+	d.format = format
+	return d
+}
+
+func (d dateFromStringArg) Timezone(timezone Timezone) DateFromStringArger {
+	d.timezone = &timezone
+	return d
+}
+
+func (d dateFromStringArg) OnError(expression interface{}) DateFromStringArger {
+	d.onError = expression
+	return d
+}
+
+func (d dateFromStringArg) OnNull(expression interface{}) DateFromStringArger {
+	d.onNull = expression
+	return d
+}
+
+func DateFromStringArg(dateString interface{}) DateFromStringArger {
+	return dateFromStringArg{dateString: dateString}
+}
+
+/*
+Converts a date/time string to a date object.
+
+	{
+		$dateFromString: {
+			dateString: <dateStringExpression>,
+			format: <formatStringExpression>,
+			timezone: <tzExpression>,
+			onError: <onErrorExpression>,
+			onNull: <onNullExpression>
+		}
+	}
+*/
+func DateFromString(
+	arg DateFromStringArger,
+) bson.M {
+	return bson.M{
+		"$dateFromString": arg.formatDateFromStringArg(),
+	}
+}
+
+type DateToStringArger interface {
+	formatDateToStringArg() bson.M
+	Format(format string) DateToStringArger
+	Timezone(timezone Timezone) DateToStringArger
+	OnNull(expression interface{}) DateToStringArger
+}
+
+type dateToStringArg struct {
+	date     interface{}
+	format   string
+	timezone *Timezone
+	onNull   interface{}
+}
+
+func (d dateToStringArg) formatDateToStringArg() bson.M {
+	b := bson.M{
+		"date": d.date,
+	}
+	if d.format != "" {
+		b["format"] = d.format
+	}
+	if d.timezone != nil {
+		b["timezone"] = *d.timezone
+	}
+	if d.onNull != nil {
+		b["onNull"] = d.onNull
+	}
+	return b
+}
+
+func (d dateToStringArg) Format(format string) DateToStringArger {
+	d.format = format
+	return d
+}
+
+func (d dateToStringArg) Timezone(timezone Timezone) DateToStringArger {
+	d.timezone = &timezone
+	return d
+}
+
+func (d dateToStringArg) OnNull(expression interface{}) DateToStringArger {
+	d.onNull = expression
+	return d
+}
+
+func DateToStringArg(date interface{}) DateToStringArger {
+	return dateToStringArg{date: date}
+}
+
+/*
+Converts a date object to a string according to a user-specified format.
+
+	{
+		$dateToString: {
+			date: <dateExpression>,
+			format: <formatString>,
+			timezone: <tzExpression>,
+			onNull: <expression>
+		}
+	}
+*/
+func DateToString(
+	arg DateToStringArger,
+) bson.M {
+	return bson.M{
+		"$dateToString": arg.formatDateToStringArg(),
+	}
+}
+
+// Return { operator: <dateExpression> } or { operator: { date: <dateExpression>, timezone: <tzExpression> } } if timezone is set
+func datePart(
+	operator string,
+	date interface{},
+	timezone ...Timezone,
+) bson.M {
+	if len(timezone) == 0 {
+		return bson.M{
+			operator: date,
+		}
+	}
+	return bson.M{
+		operator: dateWithTimezone(date, timezone...),
+	}
+}
+
+// Returns the year portion of a date.
+func Year(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$year", date, timezone...)
+}
+
+// Returns the month of a date as a number between 1 and 12.
+func Month(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$month", date, timezone...)
+}
+
+// Returns the day of the month for a date as a number between 1 and 31.
+func DayOfMonth(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$dayOfMonth", date, timezone...)
+}
+
+// Returns the day of the week for a date as a number between 1 (Sunday) and 7 (Saturday).
+func DayOfWeek(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$dayOfWeek", date, timezone...)
+}
+
+// Returns the day of the year for a date as a number between 1 and 366.
+func DayOfYear(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$dayOfYear", date, timezone...)
+}
+
+// Returns the hour portion of a date as a number between 0 and 23.
+func Hour(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$hour", date, timezone...)
+}
+
+// Returns the minute portion of a date as a number between 0 and 59.
+func Minute(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$minute", date, timezone...)
+}
+
+// Returns the second portion of a date as a number between 0 and 59, 60 for leap seconds.
+func Second(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$second", date, timezone...)
+}
+
+// Returns the millisecond portion of a date as an integer between 0 and 999.
+func Millisecond(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$millisecond", date, timezone...)
+}
+
+// Returns the week of the year for a date as a number between 0 and 53, weeks begin on Sundays.
+func Week(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$week", date, timezone...)
+}
+
+// Returns the week number in ISO 8601 format, ranging from 1 to 53.
+func IsoWeek(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$isoWeek", date, timezone...)
+}
+
+// Returns the year number in ISO 8601 format.
+func IsoWeekYear(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$isoWeekYear", date, timezone...)
+}
+
+// Returns the weekday number in ISO 8601 format, ranging from 1 (Monday) to 7 (Sunday).
+func IsoDayOfWeek(date interface{}, timezone ...Timezone) bson.M {
+	return datePart("$isoDayOfWeek", date, timezone...)
+}
+
+func ToDate(
+	expression interface{},
+) bson.M {
+	return bson.M{
+		"$toDate": expression,
+	}
+}
+
+/*
+Returns a random float between 0 and 1 each time it is called.
+
+$rand has the following syntax:
+	{ $rand: {} }
+*/
+func Rand() bson.M {
+	return bson.M{
+		"$rand": bson.M{},
+	}
+}
+
+func Literal(
+	value interface{},
+) bson.M {
+	return bson.M{
+		"$literal": value,
+	}
+}
+
+/*
+Combines multiple documents into a single document.
+
+	{ $mergeObjects: [ <document1>, <document2>, ... ] }
+
+With one document it can be used as accumulator in $group and $bucket stages
+
+	{ $mergeObjects: <document> }
+*/
+func MergeObjects(
+	docs ...interface{},
+) bson.M {
+	return bson.M{
+		"$mergeObjects": condDefaultOrValue(bson.A(docs), len(docs) == 1, docs[0]),
+	}
+}
+
+// Field names that contain "$" or "." are wrapped in $literal, otherwise they are parsed as expressions
+func fieldName(field string) interface{} {
+	if strings.ContainsAny(field, "$.") {
+		return Literal(field)
+	}
+	return field
+}
+
+/*
+Adds, updates, or removes a specified field in a document.
+
+	{
+		$setField: {
+			field: <String>,
+			input: <Object>,
+			value: <Expression>
+		}
+	}
+
+field that contains "$" or "." is wrapped in $literal.
+*/
+func SetField(
+	field string,
+	input interface{},
+	value interface{},
+) bson.M {
+	return bson.M{
+		"$setField": bson.M{
+			"field": fieldName(field),
+			"input": input,
+			"value": value,
+		},
+	}
+}
+
+/*
+Returns the value of a specified field from a document.
+
+	{
+		$getField: {
+			field: <String>,
+			input: <Object>
+		}
+	}
+
+If input is nil it's omitted and $$CURRENT is used,
+short form { $getField: <String> } is used if field is not wrapped in $literal.
+field that contains "$" or "." is wrapped in $literal.
+*/
+func GetField(
+	field string,
+	input interface{},
+) bson.M {
+	name := fieldName(field)
+	if input == nil {
+		if _, ok := name.(string); ok {
+			return bson.M{
+				"$getField": name,
+			}
+		}
+
+		return bson.M{
+			"$getField": bson.M{
+				"field": name,
+			},
+		}
+	}
+
+	return bson.M{
+		"$getField": bson.M{
+			"field": name,
+			"input": input,
+		},
+	}
+}
+
+/*
+Removes a specified field in a document.
+
+	{
+		$unsetField: {
+			field: <String>,
+			input: <Object>
+		}
+	}
+
+Works like SetField with value $$REMOVE, field that contains "$" or "." is wrapped in $literal.
+*/
+func UnsetField(
+	field string,
+	input interface{},
+) bson.M {
+	return bson.M{
+		"$unsetField": bson.M{
+			"field": fieldName(field),
+			"input": input,
+		},
+	}
+}
+
+func AllElementsTrue(
+	expression interface{},
+) bson.M {
+	return bson.M{
+		"$allElementsTrue": bson.A{expression},
+	}
+}
+
+func AnyElemetsTrue(
+	expression interface{},
+) bson.M {
+	return bson.M{
+		"$anyElementTrue": bson.A{expression},
+	}
+}
+
+func SetDifference(
+	left,
+	right interface{},
+) bson.M {
+	return bson.M{
+		"$setDifference": bson.A{left, right},
 	}
 }
 
@@ -891,355 +2017,1188 @@ func SetEquals(
 	expressions ...interface{},
 ) bson.M {
 	return bson.M{
-		"$setEquals": bson.A(expressions),
+		"$setEquals": bson.A(expressions),
+	}
+}
+
+func SetIntersection(
+	arrays ...interface{},
+) bson.M {
+	return bson.M{
+		"$setIntersection": bson.A(arrays),
+	}
+}
+
+func SetIsSubset(
+	left,
+	right interface{},
+) bson.M {
+	return bson.M{
+		"$setIsSubset": bson.A{left, right},
+	}
+}
+
+func SetUnion(
+	expressions ...interface{},
+) bson.M {
+	return bson.M{
+		"$setUnion": bson.A(expressions),
+	}
+}
+
+func Concat(
+	expressions ...interface{},
+) bson.M {
+	return bson.M{
+		"$concat": bson.A(expressions),
+	}
+}
+
+func trimBody(
+	input interface{},
+	chars interface{},
+) bson.M {
+	b := bson.M{
+		"input": input,
+	}
+	if chars != nil {
+		b["chars"] = chars
+	}
+	return b
+}
+
+func LTrimChars(
+	input interface{},
+	chars interface{},
+) bson.M {
+	return bson.M{
+		"$ltrim": trimBody(input, chars),
+	}
+}
+
+func LTrim(
+	input interface{},
+) bson.M {
+	return LTrimChars(input, nil)
+}
+
+func regexBody(
+	input,
+	regex interface{},
+	opts ...options.RegexOptions,
+) bson.M {
+	b := bson.M{
+		"input": input,
+		"regex": regex,
+	}
+
+	if options := options.MergeRegexOptions(opts...).BuildRegexOption(); options != "" {
+		b["options"] = options
+	}
+	return b
+}
+
+func RegexFind(
+	input,
+	regex interface{},
+	opts ...options.RegexOptions,
+) bson.M {
+	return bson.M{
+		"$regexFind": regexBody(input, regex, opts...),
+	}
+}
+
+func RegexFindAll(
+	input,
+	regex interface{},
+	opts ...options.RegexOptions,
+) bson.M {
+	return bson.M{
+		"$regexFindAll": regexBody(input, regex, opts...),
+	}
+}
+
+func RegexMatch(
+	input,
+	regex interface{},
+	opts ...options.RegexOptions,
+) bson.M {
+	return bson.M{
+		"$regexMatch": regexBody(input, regex, opts...),
+	}
+}
+
+func replace(
+	op string,
+	input,
+	find,
+	replacement interface{},
+) bson.M {
+	return bson.M{
+		op: bson.M{
+			"input":       input,
+			"find":        find,
+			"replacement": replacement,
+		},
+	}
+}
+
+func ReplaceOne(
+	input,
+	find,
+	replacement interface{},
+) bson.M {
+	return replace("$replaceOne", input, find, replacement)
+}
+
+func ReplaceAll(
+	input,
+	find,
+	replacement interface{},
+) bson.M {
+	return replace("$replaceAll", input, find, replacement)
+}
+
+func RTtrim(
+	input interface{},
+) bson.M {
+	return RTrimChars(input, nil)
+}
+
+func RTrimChars(
+	input interface{},
+	chars interface{},
+) bson.M {
+	return bson.M{
+		"$rtrim": trimBody(input, chars),
+	}
+}
+
+func Split(
+	input,
+	delimeter interface{},
+) bson.M {
+	return bson.M{
+		"$split": bson.A{
+			input,
+			delimeter,
+		},
+	}
+}
+
+/*
+Searches a string for an occurrence of a substring and returns the UTF-8 byte index (zero-based) of the first occurrence. If the substring is not found, returns -1.
+
+$indexOfBytes has the following operator expression syntax:
+	{ $indexOfBytes: [ <string expression>, <substring expression>, <start>, <end> ] }
+
+start and end are optional, see IndexOfOptionalParamsArgs.
+*/
+func IndexOfBytes(
+	stringExpression,
+	substringExpression interface{},
+	optionalParams ...IndexOfArrayOptionalParams,
+) bson.M {
+	return bson.M{
+		"$indexOfBytes": indexOfArrayOptionalParams{}.
+			merge(optionalParams...).
+			format(stringExpression, substringExpression),
+	}
+}
+
+/*
+Searches a string for an occurrence of a substring and returns the UTF-8 code point index (zero-based) of the first occurrence. If the substring is not found, returns -1.
+
+$indexOfCP has the following operator expression syntax:
+	{ $indexOfCP: [ <string expression>, <substring expression>, <start>, <end> ] }
+
+start and end are optional, see IndexOfOptionalParamsArgs.
+*/
+func IndexOfCP(
+	stringExpression,
+	substringExpression interface{},
+	optionalParams ...IndexOfArrayOptionalParams,
+) bson.M {
+	return bson.M{
+		"$indexOfCP": indexOfArrayOptionalParams{}.
+			merge(optionalParams...).
+			format(stringExpression, substringExpression),
+	}
+}
+
+func StrLenBytes(
+	input interface{},
+) bson.M {
+	return bson.M{
+		"$strLenBytes": input,
+	}
+}
+
+func StrLenCP(
+	input interface{},
+) bson.M {
+	return bson.M{
+		"$strLenCP": input,
+	}
+}
+
+func StrCaseCMP(
+	stringExpression,
+	caseExpression interface{},
+) bson.M {
+	return bson.M{
+		"$strcasecmp": bson.A{stringExpression, caseExpression},
+	}
+}
+
+func SubStrBytes(
+	stringExpression,
+	byteIndex,
+	byteCount interface{},
+) bson.M {
+	return bson.M{
+		"$substrBytes": bson.A{stringExpression, byteIndex, byteCount},
+	}
+}
+
+/*
+Deprecated in MongoDB, use SubStrBytes or SubStrCP.
+
+$substr has the following syntax:
+	{ $substr: [ <string>, <start>, <length> ] }
+*/
+func SubStr(
+	stringExpression,
+	start,
+	length interface{},
+) bson.M {
+	return bson.M{
+		"$substr": bson.A{stringExpression, start, length},
+	}
+}
+
+func SubStrCP(
+	stringExpression,
+	codepointIndex,
+	codepointCount interface{},
+) bson.M {
+	return bson.M{
+		"$substrCP": bson.A{stringExpression, codepointIndex, codepointCount},
+	}
+}
+
+func ToLower(
+	expression interface{},
+) bson.M {
+	return bson.M{
+		"$toLower": expression,
+	}
+}
+
+func ToString(
+	expression interface{},
+) bson.M {
+	return bson.M{
+		"$toString": expression,
+	}
+}
+
+func Trim(
+	input interface{},
+) bson.M {
+	return TrimChars(input, nil)
+}
+
+func TrimChars(
+	input interface{},
+	chars interface{},
+) bson.M {
+	return bson.M{
+		"$trim": trimBody(input, chars),
+	}
+}
+
+func ToUpper(
+	expression interface{},
+) bson.M {
+	return bson.M{
+		"$toUpper": expression,
+	}
+}
+
+type ConvertOptionalsArger interface {
+	convertOptionals() convertOptionals
+	OnNull(interface{}) ConvertOptionalsArger
+	OnError(interface{}) ConvertOptionalsArger
+}
+
+type convertOptionals struct {
+	onError interface{}
+	onNull  interface{}
+}
+
+func (c convertOptionals) convertOptionals() convertOptionals {
+	return c
+}
+
+func (c convertOptionals) Merge(opts ...ConvertOptionalsArger) convertOptionals {
+	for _, opt := range opts {
+		o := opt.convertOptionals()
+		if o.onNull != nil {
+			c.onNull = o.onNull
+		}
+		if o.onError != nil {
+			c.onError = o.onError
+		}
+	}
+	return c
+}
+
+func (c convertOptionals) format() bson.M {
+	optionals := bson.M{}
+	if c.onNull != nil {
+		optionals["onNull"] = c.onNull
+	}
+	if c.onError != nil {
+		optionals["onError"] = c.onError
+	}
+	return optionals
+}
+
+func (c convertOptionals) OnNull(v interface{}) ConvertOptionalsArger {
+	c.onNull = v
+	return c
+}
+
+func (c convertOptionals) OnError(v interface{}) ConvertOptionalsArger {
+	c.onError = v
+	return c
+}
+
+func ConvertOptionalsArgs() ConvertOptionalsArger {
+	return convertOptionals{}
+}
+
+func convert(
+	input interface{},
+	to interface{},
+	opts ...ConvertOptionalsArger,
+) bson.M {
+	return bson.M{
+		"$convert": utils.MergeBsonM(
+			bson.M{
+				"input": input,
+				"to":    to,
+			},
+			convertOptionals{}.Merge(opts...).format(),
+		),
 	}
 }
 
-func SetIntersection(
-	arrays ...interface{},
+/*
+Converts a value to a specified type.
+
+	{
+		$convert: {
+			input: <expression>,
+			to: <type expression>,
+			onError: <expression>,
+			onNull: <expression>
+		}
+	}
+
+to is string identifier of type, onError and onNull are optional.
+*/
+func Convert(
+	input interface{},
+	to types.Type,
+	opts ...ConvertOptionalsArger,
+) bson.M {
+	return convert(input, to.StringIdentifier(), opts...)
+}
+
+// Work like Convert but to is numeric identifier of type
+func ConvertNumeric(
+	input interface{},
+	to types.Type,
+	opts ...ConvertOptionalsArger,
+) bson.M {
+	return convert(input, to.NumericIdentifier(), opts...)
+}
+
+// Type Expression Operators
+
+/*
+Returns a string that specifies the BSON type of the argument.
+
+	{ $type: <expression> }
+*/
+func Type(
+	expression interface{},
 ) bson.M {
 	return bson.M{
-		"$setIntersection": bson.A(arrays),
+		"$type": expression,
 	}
 }
 
-func SetIsSubset(
-	left,
-	right interface{},
+/*
+Checks if the specified expression resolves to one of the numeric BSON types: int, long, double, decimal.
+
+	{ $isNumber: <expression> }
+*/
+func IsNumber(
+	expression interface{},
 ) bson.M {
 	return bson.M{
-		"$setIsSubset": bson.A{left, right},
+		"$isNumber": expression,
 	}
 }
 
-func SetUnion(
-	expressions ...interface{},
+/*
+Converts a value to a boolean.
+
+	{ $toBool: <expression> }
+*/
+func ToBool(
+	expression interface{},
 ) bson.M {
 	return bson.M{
-		"$setUnion": bson.A(expressions),
+		"$toBool": expression,
 	}
 }
 
-func Concat(
-	expressions ...interface{},
+/*
+Converts a value to an integer. If the value cannot be converted to an integer, $toInt errors.
+
+	{ $toInt: <expression> }
+*/
+func ToInt(
+	expression interface{},
 ) bson.M {
 	return bson.M{
-		"$concat": bson.A(expressions),
+		"$toInt": expression,
 	}
 }
 
-func trimBody(
-	input interface{},
-	chars interface{},
+/*
+Converts a value to a long. If the value cannot be converted to a long, $toLong errors.
+
+	{ $toLong: <expression> }
+*/
+func ToLong(
+	expression interface{},
 ) bson.M {
-	b := bson.M{
-		"input": input,
-	}
-	if chars != nil {
-		b["chars"] = chars
+	return bson.M{
+		"$toLong": expression,
 	}
-	return b
 }
 
-func LTrimChars(
-	input interface{},
-	chars interface{},
+/*
+Converts a value to a double. If the value cannot be converted to a double, $toDouble errors.
+
+	{ $toDouble: <expression> }
+*/
+func ToDouble(
+	expression interface{},
 ) bson.M {
 	return bson.M{
-		"$ltrim": trimBody(input, chars),
+		"$toDouble": expression,
 	}
 }
 
-func LTrim(
-	input interface{},
+/*
+Converts a value to a decimal. If the value cannot be converted to a decimal, $toDecimal errors.
+
+	{ $toDecimal: <expression> }
+*/
+func ToDecimal(
+	expression interface{},
 ) bson.M {
-	return LTrimChars(input, nil)
+	return bson.M{
+		"$toDecimal": expression,
+	}
 }
 
-func regexBody(
-	input,
-	regex interface{},
-	opts ...options.RegexOptions,
+/*
+Converts a value to an ObjectId. If the value cannot be converted to an ObjectId, $toObjectId errors.
+
+	{ $toObjectId: <expression> }
+*/
+func ToObjectId(
+	expression interface{},
 ) bson.M {
-	b := bson.M{
-		"input": input,
-		"regex": regex,
+	return bson.M{
+		"$toObjectId": expression,
 	}
+}
 
-	if options := options.MergeRegexOptions(opts...).BuildRegexOption(); options != "" {
-		b["options"] = options
+func AddToSet(expression interface{}) bson.M {
+	return bson.M{
+		"$addToSet": expression,
 	}
-	return b
 }
 
-func RegexFind(
-	input,
-	regex interface{},
-	opts ...options.RegexOptions,
+func Avg(
+	expressions ...interface{},
 ) bson.M {
 	return bson.M{
-		"$regexFind": regexBody(input, regex, opts...),
+		"$avg": condDefaultOrValue(bson.A(expressions), len(expressions) == 1, expressions[0]),
 	}
 }
 
-func RegexFindAll(
-	input,
-	regex interface{},
-	opts ...options.RegexOptions,
-) bson.M {
+func Count() bson.M {
 	return bson.M{
-		"$regexFindAll": regexBody(input, regex, opts...),
+		"$count": bson.M{},
 	}
 }
 
-func RegexMatch(
-	input,
-	regex interface{},
-	opts ...options.RegexOptions,
+func Push(expression interface{}) bson.M {
+	return bson.M{
+		"$push": expression,
+	}
+}
+
+func Sum(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$sum": condDefaultOrValue(bson.A(expressions), len(expressions) == 1, expressions[0]),
+	}
+}
+
+func Multiply(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$multiply": bson.A(expressions),
+	}
+}
+
+// Accumulator Operators
+
+/*
+Returns the maximum value.
+
+	{ $max: <expression> }
+
+In $project and $addFields stages can be used with several expressions
+
+	{ $max: [ <expression1>, <expression2> ... ]  }
+*/
+func Max(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$max": condDefaultOrValue(bson.A(expressions), len(expressions) == 1, expressions[0]),
+	}
+}
+
+/*
+Returns the minimum value.
+
+	{ $min: <expression> }
+
+In $project and $addFields stages can be used with several expressions
+
+	{ $min: [ <expression1>, <expression2> ... ]  }
+*/
+func Min(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$min": condDefaultOrValue(bson.A(expressions), len(expressions) == 1, expressions[0]),
+	}
+}
+
+/*
+Calculates the population standard deviation of the input values.
+
+	{ $stdDevPop: <expression> }
+*/
+func StdDevPop(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$stdDevPop": condDefaultOrValue(bson.A(expressions), len(expressions) == 1, expressions[0]),
+	}
+}
+
+/*
+Calculates the sample standard deviation of the input values.
+
+	{ $stdDevSamp: <expression> }
+*/
+func StdDevSamp(expressions ...interface{}) bson.M {
+	return bson.M{
+		"$stdDevSamp": condDefaultOrValue(bson.A(expressions), len(expressions) == 1, expressions[0]),
+	}
+}
+
+func sortedOutput(
+	operator string,
+	output interface{},
+	n interface{},
+	sortBy ...sort.SortArger,
 ) bson.M {
+	b := bson.M{
+		"sortBy": sort.Spec(sortBy...),
+		"output": output,
+	}
+	if n != nil {
+		b["n"] = n
+	}
 	return bson.M{
-		"$regexMatch": regexBody(input, regex, opts...),
+		operator: b,
 	}
 }
 
-func replace(
-	op string,
-	input,
-	find,
-	replacement interface{},
+/*
+Returns the top element within a group according to the specified sort order.
+
+	{
+		$top: {
+			sortBy: { <field1>: <sort order>, <field2>: <sort order> ... },
+			output: <expression>
+		}
+	}
+*/
+func Top(
+	output interface{},
+	sortBy ...sort.SortArger,
+) bson.M {
+	return sortedOutput("$top", output, nil, sortBy...)
+}
+
+/*
+Returns an aggregation of the top n elements within a group, according to the specified sort order.
+
+	{
+		$topN: {
+			n: <expression>,
+			sortBy: { <field1>: <sort order>, <field2>: <sort order> ... },
+			output: <expression>
+		}
+	}
+*/
+func TopN(
+	n interface{},
+	output interface{},
+	sortBy ...sort.SortArger,
+) bson.M {
+	return sortedOutput("$topN", output, n, sortBy...)
+}
+
+/*
+Returns the bottom element within a group according to the specified sort order.
+
+	{
+		$bottom: {
+			sortBy: { <field1>: <sort order>, <field2>: <sort order> ... },
+			output: <expression>
+		}
+	}
+*/
+func Bottom(
+	output interface{},
+	sortBy ...sort.SortArger,
+) bson.M {
+	return sortedOutput("$bottom", output, nil, sortBy...)
+}
+
+/*
+Returns an aggregation of the bottom n elements within a group, according to the specified sort order.
+
+	{
+		$bottomN: {
+			n: <expression>,
+			sortBy: { <field1>: <sort order>, <field2>: <sort order> ... },
+			output: <expression>
+		}
+	}
+*/
+func BottomN(
+	n interface{},
+	output interface{},
+	sortBy ...sort.SortArger,
+) bson.M {
+	return sortedOutput("$bottomN", output, n, sortBy...)
+}
+
+func inputN(
+	operator string,
+	input interface{},
+	n interface{},
 ) bson.M {
 	return bson.M{
-		op: bson.M{
-			"input":       input,
-			"find":        find,
-			"replacement": replacement,
+		operator: bson.M{
+			"input": input,
+			"n":     n,
 		},
 	}
 }
 
-func ReplaceOne(
-	input,
-	find,
-	replacement interface{},
+/*
+Returns an aggregation of the first n elements within a group.
+Also can be used as array operator to return n elements from the beginning of an array.
+
+	{ $firstN: { input: <expression>, n: <expression> } }
+*/
+func FirstN(
+	input interface{},
+	n interface{},
 ) bson.M {
-	return replace("$replaceOne", input, find, replacement)
+	return inputN("$firstN", input, n)
 }
 
-func ReplaceAll(
-	input,
-	find,
-	replacement interface{},
+/*
+Returns an aggregation of the last n elements within a group.
+Also can be used as array operator to return n elements from the end of an array.
+
+	{ $lastN: { input: <expression>, n: <expression> } }
+*/
+func LastN(
+	input interface{},
+	n interface{},
 ) bson.M {
-	return replace("$replaceAll", input, find, replacement)
+	return inputN("$lastN", input, n)
 }
 
-func RTtrim(
+/*
+Returns an aggregation of the maximum value n elements within a group.
+Also can be used as array operator to return n largest values of an array.
+
+	{ $maxN: { input: <expression>, n: <expression> } }
+*/
+func MaxN(
 	input interface{},
+	n interface{},
 ) bson.M {
-	return RTrimChars(input, nil)
+	return inputN("$maxN", input, n)
 }
 
-func RTrimChars(
+/*
+Returns an aggregation of the minimum value n elements within a group.
+Also can be used as array operator to return n smallest values of an array.
+
+	{ $minN: { input: <expression>, n: <expression> } }
+*/
+func MinN(
 	input interface{},
-	chars interface{},
+	n interface{},
 ) bson.M {
-	return bson.M{
-		"$rtrim": trimBody(input, chars),
-	}
+	return inputN("$minN", input, n)
 }
 
-func Split(
-	input,
-	delimeter interface{},
+// The only method supported by MongoDB for $median and $percentile
+const approximateMethod = "approximate"
+
+/*
+Returns an approximation of the median, the 50th percentile, as a scalar value.
+
+	{
+		$median: {
+			input: <number>,
+			method: "approximate"
+		}
+	}
+*/
+func Median(
+	input interface{},
 ) bson.M {
 	return bson.M{
-		"$split": bson.A{
-			input,
-			delimeter,
+		"$median": bson.M{
+			"input":  input,
+			"method": approximateMethod,
 		},
 	}
 }
 
-func StrLenBytes(
+/*
+Returns an array of scalar values that correspond to specified percentile values.
+
+	{
+		$percentile: {
+			input: <expression>,
+			p: [ <expression1>, <expression2>, ... ],
+			method: "approximate"
+		}
+	}
+
+p values must resolve to numbers between 0.0 and 1.0 inclusive.
+*/
+func Percentile(
 	input interface{},
+	p ...interface{},
 ) bson.M {
 	return bson.M{
-		"$strLenBytes": input,
+		"$percentile": bson.M{
+			"input":  input,
+			"p":      bson.A(p),
+			"method": approximateMethod,
+		},
 	}
 }
 
-func StrLenCP(
-	input interface{},
-) bson.M {
+type TimeUnit int
+
+const (
+	UnitYear TimeUnit = iota
+	UnitQuarter
+	UnitMonth
+	UnitWeek
+	UnitDay
+	UnitHour
+	UnitMinute
+	UnitSecond
+	UnitMillisecond
+)
+
+func (u TimeUnit) String() string {
+	return []string{
+		"year",
+		"quarter",
+		"month",
+		"week",
+		"day",
+		"hour",
+		"minute",
+		"second",
+		"millisecond",
+	}[u]
+}
+
+// Window Operators
+
+/*
+Returns the document position (known as the rank) relative to other documents in the $setWindowFields stage partition.
+
+	{ $rank: { } }
+*/
+func Rank() bson.M {
 	return bson.M{
-		"$strLenCP": input,
+		"$rank": bson.M{},
 	}
 }
 
-func StrCaseCMP(
-	stringExpression,
-	caseExpression interface{},
-) bson.M {
+/*
+Returns the document position (known as the rank) relative to other documents in the $setWindowFields stage partition.
+Documents with the same value have the same rank, there are no gaps in ranks.
+
+	{ $denseRank: { } }
+*/
+func DenseRank() bson.M {
 	return bson.M{
-		"$strcasecmp": bson.A{stringExpression, caseExpression},
+		"$denseRank": bson.M{},
 	}
 }
 
-func SubStrBytes(
-	stringExpression,
-	byteIndex,
-	byteCount interface{},
-) bson.M {
+/*
+Returns the position of a document (known as the document number) in the $setWindowFields stage partition.
+
+	{ $documentNumber: { } }
+*/
+func DocumentNumber() bson.M {
 	return bson.M{
-		"$substrBytes": bson.A{stringExpression, byteIndex, byteCount},
+		"$documentNumber": bson.M{},
 	}
 }
 
-func SubStrCP(
-	stringExpression,
-	codepointIndex,
-	codepointCount interface{},
+/*
+Returns the value from an expression applied to a document in a specified position relative to the current document in the $setWindowFields stage partition.
+
+	{
+		$shift: {
+			output: <output expression>,
+			by: <integer>,
+			default: <default expression>
+		}
+	}
+
+If defaultValue is nil default is not set.
+*/
+func Shift(
+	output interface{},
+	by int,
+	defaultValue interface{},
 ) bson.M {
+	b := bson.M{
+		"output": output,
+		"by":     by,
+	}
+	if defaultValue != nil {
+		b["default"] = defaultValue
+	}
+
 	return bson.M{
-		"$substrCP": bson.A{stringExpression, codepointIndex, codepointCount},
+		"$shift": b,
 	}
 }
 
-func ToLower(
-	expression interface{},
+func inputWithUnit(input interface{}, unit ...TimeUnit) bson.M {
+	b := bson.M{
+		"input": input,
+	}
+	if len(unit) > 0 {
+		if unit[0] < UnitWeek {
+			panic(fmt.Sprintf("unit must be week or smaller, got %s", unit[0].String()))
+		}
+		b["unit"] = unit[0].String()
+	}
+	return b
+}
+
+/*
+Returns the average rate of change within the specified window.
+
+	{
+		$derivative: {
+			input: <expression>,
+			unit: <time unit>
+		}
+	}
+
+unit is optional, it's required if sortBy field is date.
+Only week, day, hour, minute, second and millisecond units are allowed, panic on coarser units.
+*/
+func Derivative(
+	input interface{},
+	unit ...TimeUnit,
 ) bson.M {
 	return bson.M{
-		"$toLower": expression,
+		"$derivative": inputWithUnit(input, unit...),
 	}
 }
 
-func ToString(
-	expression interface{},
+/*
+Returns the approximation of the area under a curve.
+
+	{
+		$integral: {
+			input: <expression>,
+			unit: <time unit>
+		}
+	}
+
+unit is optional, it's required if sortBy field is date.
+Only week, day, hour, minute, second and millisecond units are allowed, panic on coarser units.
+*/
+func Integral(
+	input interface{},
+	unit ...TimeUnit,
 ) bson.M {
 	return bson.M{
-		"$toString": expression,
+		"$integral": inputWithUnit(input, unit...),
 	}
 }
 
-func Trim(
+/*
+Returns the exponential moving average of numeric expressions applied to documents in a partition,
+n is a number of historical documents that have a significant mathematical weight.
+
+	{
+		$expMovingAvg: {
+			input: <input expression>,
+			N: <integer>
+		}
+	}
+*/
+func ExpMovingAvgN(
 	input interface{},
+	n int,
 ) bson.M {
-	return TrimChars(input, nil)
+	return bson.M{
+		"$expMovingAvg": bson.M{
+			"input": input,
+			"N":     n,
+		},
+	}
 }
 
-func TrimChars(
+/*
+Work like ExpMovingAvgN but use alpha, alpha is exponential decay value to use in the calculation.
+
+	{
+		$expMovingAvg: {
+			input: <input expression>,
+			alpha: <float>
+		}
+	}
+*/
+func ExpMovingAvgAlpha(
 	input interface{},
-	chars interface{},
+	alpha float64,
 ) bson.M {
 	return bson.M{
-		"$trim": trimBody(input, chars),
+		"$expMovingAvg": bson.M{
+			"input": input,
+			"alpha": alpha,
+		},
 	}
 }
 
-func ToUpper(
-	expression interface{},
+/*
+Returns the population covariance of two numeric expressions that are evaluated using documents in the $setWindowFields stage window.
+
+	{ $covariancePop: [ <numeric expression 1>, <numeric expression 2> ] }
+*/
+func CovariancePop(
+	first,
+	second interface{},
 ) bson.M {
 	return bson.M{
-		"$toUpper": expression,
+		"$covariancePop": bson.A{first, second},
 	}
 }
 
-type ConvertOptionalsArger interface {
-	convertOptionals()
-	OnNull(interface{}) ConvertOptionalsArger
-	OnError(interface{}) ConvertOptionalsArger
-}
+/*
+Returns the sample covariance of two numeric expressions that are evaluated using documents in the $setWindowFields stage window.
 
-type convertOptionals struct {
-	onError interface{}
-	onNull  interface{}
+	{ $covarianceSamp: [ <numeric expression 1>, <numeric expression 2> ] }
+*/
+func CovarianceSamp(
+	first,
+	second interface{},
+) bson.M {
+	return bson.M{
+		"$covarianceSamp": bson.A{first, second},
+	}
 }
 
-func (convertOptionals) convertOptionals() {}
+/*
+Last observation carried forward. Sets values for null and missing fields in a window to the last non-null value for the field.
 
-func (c convertOptionals) Merge(opts ...ConvertOptionalsArger) convertOptionals {
-	for _, opt := range opts {
-		c.onNull = opt.OnNull(c.onNull)
-		c.onError = opt.OnError(c.onError)
+	{ $locf: <expression> }
+*/
+func Locf(
+	expression interface{},
+) bson.M {
+	return bson.M{
+		"$locf": expression,
 	}
-	return c
 }
 
-func (c convertOptionals) OnNull(v interface{}) ConvertOptionalsArger {
-	c.onNull = v
-	return c
+// Custom Aggregation Expression Operators
+
+// Only JavaScript is supported by MongoDB
+const langJS = "js"
+
+// Body of JavaScript function, use FunctionCode or FunctionJavaScript
+type FunctionBody interface {
+	functionBody() interface{}
 }
 
-func (c convertOptionals) OnError(v interface{}) ConvertOptionalsArger {
-	c.onError = v
-	return c
+type functionBody struct {
+	body interface{}
 }
 
-func ConvertOptionalsArgs() ConvertOptionalsArger {
-	return convertOptionals{}
+func (f functionBody) functionBody() interface{} {
+	return f.body
 }
 
-func Convert(
-	input interface{},
-	to types.Type,
-	opts ...ConvertOptionalsArger,
-) bson.M {
-	optionals := bson.M{}
+func FunctionCode(code string) FunctionBody {
+	return functionBody{body: code}
+}
+
+func FunctionJavaScript(code primitive.JavaScript) FunctionBody {
+	return functionBody{body: code}
+}
+
+/*
+Defines a custom aggregation function or expression in JavaScript.
+
 	{
-		opt := convertOptionals{}.Merge(opts...)
-		if opt.onNull != nil {
-			optionals["onNull"] = opt.onNull
-		}
-		if opt.onError != nil {
-			optionals["onError"] = opt.onError
+		$function: {
+			body: <code>,
+			args: <array expression>,
+			lang: "js"
 		}
 	}
-
+*/
+func Function(
+	body FunctionBody,
+	args ...interface{},
+) bson.M {
 	return bson.M{
-		"$convert": utils.MergeBsonM(
-			bson.M{
-				"input": input,
-				"to":    to.StringIdentifier(),
-			},
-			optionals,
-		),
+		"$function": bson.M{
+			"body": body.functionBody(),
+			"args": append(bson.A{}, args...),
+			"lang": langJS,
+		},
 	}
 }
 
-func AddToSet(expression interface{}) bson.M {
-	return bson.M{
-		"$addToSet": expression,
-	}
+type AccumulatorArger interface {
+	formatAccumulatorArg() bson.M
+	InitArgs(args ...interface{}) AccumulatorArger
+	AccumulateArgs(args ...interface{}) AccumulatorArger
+	Finalize(body FunctionBody) AccumulatorArger
 }
 
-func Avg(
-	expressions ...interface{},
-) bson.M {
-	return bson.M{
-		"$avg": condDefaultOrValue(bson.A(expressions), len(expressions) == 1, expressions[0]),
-	}
+type accumulatorArg struct {
+	init           FunctionBody
+	initArgs       bson.A
+	accumulate     FunctionBody
+	accumulateArgs bson.A
+	merge          FunctionBody
+	finalize       FunctionBody
 }
 
-func Count() bson.M {
-	return bson.M{
-		"$count": bson.M{},
+func (a accumulatorArg) formatAccumulatorArg() bson.M {
+	b := bson.M{
+		"init":           a.init.functionBody(),
+		"accumulate":     a.accumulate.functionBody(),
+		"accumulateArgs": append(bson.A{}, a.accumulateArgs...),
+		"merge":          a.merge.functionBody(),
+		"lang":           langJS,
 	}
+	if a.initArgs != nil {
+		b["initArgs"] = a.initArgs
+	}
+	if a.finalize != nil {
+		b["finalize"] = a.finalize.functionBody()
+	}
+	return b
 }
 
-func Push(expression interface{}) bson.M {
-	return bson.M{
-		"$push": expression,
-	}
+func (a accumulatorArg) InitArgs(args ...interface{}) AccumulatorArger {
+	a.initArgs = append(bson.A{}, args...)
+	return a
 }
 
-func Sum(expressions ...interface{}) bson.M {
-	return bson.M{
-		"$sum": condDefaultOrValue(bson.A(expressions), len(expressions) == 1, expressions[0]),
+func (a accumulatorArg) AccumulateArgs(args ...interface{}) AccumulatorArger {
+	a.accumulateArgs = append(bson.A{}, args...)
+	return a
+}
+
+func (a accumulatorArg) Finalize(body FunctionBody) AccumulatorArger {
+	a.finalize = body
+	return a
+}
+
+// init, accumulate and merge functions are required
+func AccumulatorArg(
+	init,
+	accumulate,
+	merge FunctionBody,
+) AccumulatorArger {
+	return accumulatorArg{
+		init:       init,
+		accumulate: accumulate,
+		merge:      merge,
 	}
 }
 
-func Multiply(expressions ...interface{}) bson.M {
+/*
+Defines a custom accumulator operator in JavaScript.
+
+	{
+		$accumulator: {
+			init: <code>,
+			initArgs: <array expression>,
+			accumulate: <code>,
+			accumulateArgs: <array expression>,
+			merge: <code>,
+			finalize: <code>,
+			lang: "js"
+		}
+	}
+
+initArgs and finalize are optional.
+*/
+func Accumulator(
+	arg AccumulatorArger,
+) bson.M {
 	return bson.M{
-		"$multiply": bson.A(expressions),
+		"$accumulator": arg.formatAccumulatorArg(),
 	}
 }
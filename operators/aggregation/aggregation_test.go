@@ -0,0 +1,565 @@
+package aggregation_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	op "github.com/0B1t322/MongoBuilder/operators/aggregation"
+	"github.com/0B1t322/MongoBuilder/operators/sort"
+	"github.com/0B1t322/MongoBuilder/operators/types"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFunc_Date(t *testing.T) {
+	t.Run(
+		"Timezone",
+		func(t *testing.T) {
+			require.Equal(t, op.Timezone("+03:30"), op.TimezoneOffset(3*time.Hour+30*time.Minute))
+			require.Equal(t, op.Timezone("-05:00"), op.TimezoneOffset(-5*time.Hour))
+			require.Equal(t, op.TimezoneUTC, op.TimezoneFromLocation(time.UTC))
+			require.Equal(t, op.TimezoneUTC, op.TimezoneFromLocation(nil))
+			require.Equal(
+				t,
+				op.Timezone("+04:00"),
+				op.TimezoneFromLocation(time.FixedZone("", 4*60*60)),
+			)
+
+			// fixed zone named as a real zone with different offset
+			require.Equal(
+				t,
+				op.Timezone("+03:00"),
+				op.TimezoneFromLocation(time.FixedZone("EST", 3*60*60)),
+			)
+
+			loc, err := time.LoadLocation("Europe/London")
+			if err == nil {
+				require.Equal(t, op.Timezone("Europe/London"), op.TimezoneFromLocation(loc))
+				// loaded location is cached
+				require.Equal(t, op.Timezone("Europe/London"), op.TimezoneFromLocation(loc))
+				// offset of London is the same only in winter
+				require.Equal(
+					t,
+					op.Timezone("+00:00"),
+					op.TimezoneFromLocation(time.FixedZone("Europe/London", 0)),
+				)
+			}
+		},
+	)
+
+	t.Run(
+		"TimeUnitFromDuration",
+		func(t *testing.T) {
+			for _, c := range []struct {
+				d      time.Duration
+				unit   op.TimeUnit
+				amount int64
+			}{
+				{14 * 24 * time.Hour, op.UnitWeek, 2},
+				{48 * time.Hour, op.UnitDay, 2},
+				{-3 * time.Hour, op.UnitHour, -3},
+				{90 * time.Minute, op.UnitMinute, 90},
+				{1500 * time.Millisecond, op.UnitMillisecond, 1500},
+				{0, op.UnitMillisecond, 0},
+			} {
+				unit, amount := op.TimeUnitFromDuration(c.d)
+				require.Equal(t, c.unit, unit, c.d.String())
+				require.Equal(t, c.amount, amount, c.d.String())
+			}
+		},
+	)
+
+	t.Run(
+		"DateAdd",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$dateAdd": bson.M{
+						"startDate": "$purchaseDate",
+						"unit":      "day",
+						"amount":    int64(3),
+						"timezone":  op.Timezone("Europe/London"),
+					},
+				},
+				op.DateAddDuration("$purchaseDate", 72*time.Hour, "Europe/London"),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$dateSubtract": bson.M{
+						"startDate": "$$NOW",
+						"unit":      "month",
+						"amount":    1,
+					},
+				},
+				op.DateSubtract("$$NOW", op.UnitMonth, 1),
+			)
+		},
+	)
+
+	t.Run(
+		"DateDiffAndTrunc",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$dateDiff": bson.M{
+						"startDate":   "$start",
+						"endDate":     "$end",
+						"unit":        "week",
+						"timezone":    op.Timezone("+03:00"),
+						"startOfWeek": "monday",
+					},
+				},
+				op.DateDiff(
+					"$start",
+					"$end",
+					op.UnitWeek,
+					op.DateOptionalsArg().
+						Timezone(op.TimezoneOffset(3*time.Hour)).
+						StartOfWeek(time.Monday).
+						BinSize(2),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$dateTrunc": bson.M{
+						"date":    "$orderDate",
+						"unit":    "hour",
+						"binSize": 6,
+					},
+				},
+				op.DateTrunc("$orderDate", op.UnitHour, op.DateOptionalsArg().BinSize(6)),
+			)
+		},
+	)
+
+	t.Run(
+		"Parts",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$dateFromParts": bson.M{
+						"year":     2017,
+						"month":    2,
+						"day":      8,
+						"timezone": op.TimezoneUTC,
+					},
+				},
+				op.DateFromParts(
+					op.DateFromPartsArg().
+						Year(2017).
+						Month(2).
+						Day(8).
+						Timezone(op.TimezoneUTC),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"$dateToParts": bson.M{
+						"date":    "$date",
+						"iso8601": true,
+					},
+				},
+				op.DateToParts("$date", true),
+			)
+
+			require.Equal(t, bson.M{"$year": "$date"}, op.Year("$date"))
+			require.Equal(
+				t,
+				bson.M{
+					"$isoWeek": bson.M{
+						"date":     "$date",
+						"timezone": op.Timezone("$tz"),
+					},
+				},
+				op.IsoWeek("$date", "$tz"),
+			)
+		},
+	)
+
+	t.Run(
+		"String",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$dateFromString": bson.M{
+						"dateString": "$date",
+						"format":     "%m-%d-%Y",
+						"onNull":     "unknown",
+					},
+				},
+				op.DateFromString(
+					op.DateFromStringArg("$date").
+						Format("%m-%d-%Y").
+						OnNull("unknown"),
+				),
+			)
+		},
+	)
+}
+
+func TestFunc_Trigonometry(t *testing.T) {
+	// bearing from (lat1, lng1) to (lat2, lng2) in degrees
+	lat1, lat2 := op.DegreesToRadians("$lat1"), op.DegreesToRadians("$lat2")
+	dLng := op.DegreesToRadians(op.Substract("$lng2", "$lng1"))
+
+	require.Equal(
+		t,
+		bson.M{
+			"$radiansToDegrees": bson.M{
+				"$atan2": bson.A{
+					bson.M{
+						"$multiply": bson.A{
+							bson.M{"$sin": dLng},
+							bson.M{"$cos": lat2},
+						},
+					},
+					bson.M{
+						"$subtract": bson.A{
+							bson.M{"$multiply": bson.A{bson.M{"$cos": lat1}, bson.M{"$sin": lat2}}},
+							bson.M{"$multiply": bson.A{
+								bson.M{"$sin": lat1},
+								bson.M{"$cos": lat2},
+								bson.M{"$cos": dLng},
+							}},
+						},
+					},
+				},
+			},
+		},
+		op.RadiansToDegrees(
+			op.Atan2(
+				op.Multiply(op.Sin(dLng), op.Cos(lat2)),
+				op.Substract(
+					op.Multiply(op.Cos(lat1), op.Sin(lat2)),
+					op.Multiply(op.Sin(lat1), op.Cos(lat2), op.Cos(dLng)),
+				),
+			),
+		),
+	)
+}
+
+func TestFunc_Bitwise(t *testing.T) {
+	require.Equal(t, bson.M{"$bitAnd": bson.A{"$a", "$b"}}, op.BitAnd("$a", "$b"))
+	require.Equal(t, bson.M{"$bitNot": "$a"}, op.BitNot("$a"))
+	require.Equal(t, bson.M{"$rand": bson.M{}}, op.Rand())
+}
+
+func TestFunc_Convert(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"$convert": bson.M{
+				"input":   "$price",
+				"to":      "decimal",
+				"onError": "Error",
+				"onNull":  0,
+			},
+		},
+		op.Convert(
+			"$price",
+			types.Decimal128,
+			op.ConvertOptionalsArgs().OnError("Error"),
+			op.ConvertOptionalsArgs().OnNull(0),
+		),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"$convert": bson.M{
+				"input": "$qty",
+				"to":    int8(16),
+			},
+		},
+		op.ConvertNumeric("$qty", types.Int32),
+	)
+
+	require.Equal(t, bson.M{"$toObjectId": "$_id"}, op.ToObjectId("$_id"))
+	require.Equal(t, bson.M{"$isNumber": "$qty"}, op.IsNumber("$qty"))
+}
+
+func TestFunc_Let(t *testing.T) {
+	t.Run(
+		"Build",
+		func(t *testing.T) {
+			let := op.LetArg()
+			total := let.Var("total", op.Add("$price", "$tax"))
+			discounted := let.Var("discounted", op.Cond("$applyDiscount", 0.9, 1))
+			item := op.Var("item")
+
+			expr, err := op.Let(
+				let.In(
+					op.Multiply(
+						total.Ref(),
+						discounted,
+						op.Size(op.Map("$items", item.Name(), item.Field("qty"))),
+					),
+				),
+			)
+			require.NoError(t, err)
+			require.Equal(
+				t,
+				bson.M{
+					"$let": bson.M{
+						"vars": bson.M{
+							"total":      bson.M{"$add": bson.A{"$price", "$tax"}},
+							"discounted": bson.M{"$cond": bson.A{"$applyDiscount", 0.9, 1}},
+						},
+						"in": bson.M{
+							"$multiply": bson.A{
+								"$$total",
+								discounted,
+								bson.M{
+									"$size": bson.M{
+										"$map": bson.M{
+											"input": "$items",
+											"as":    "item",
+											"in":    "$$item.qty",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				expr,
+			)
+
+			// handles are rendered as reference
+			raw, err := bson.Marshal(bson.M{"v": discounted})
+			require.NoError(t, err)
+			require.Equal(t, "$$discounted", bson.Raw(raw).Lookup("v").StringValue())
+		},
+	)
+
+	t.Run(
+		"UndefinedVariable",
+		func(t *testing.T) {
+			let := op.LetArg()
+			let.Var("item", "$items")
+
+			_, err := op.Let(let.In(op.Size("$$itme")))
+			require.True(t, errors.Is(err, op.ErrUndefinedVariable))
+
+			// $$this is out of scope of $map input
+			_, err = op.Let(let.In(op.Map("$$this", "", "$$item")))
+			require.True(t, errors.Is(err, op.ErrUndefinedVariable))
+
+			_, err = op.Let(let.In(op.Filter("$$item", "el", op.GT("$$this", 1))))
+			require.True(t, errors.Is(err, op.ErrUndefinedVariable))
+
+			_, err = op.Let(
+				let.In(
+					op.Reduce(
+						"$$item",
+						0,
+						op.Add(op.Value(), op.This().Field("qty"), "$$ROOT.bonus", "$$outer"),
+					),
+				),
+			)
+			require.True(t, errors.Is(err, op.ErrUndefinedVariable))
+
+			_, err = op.Let(
+				let.Outer(op.Var("outer")).
+					In(
+						op.Reduce(
+							"$$item",
+							0,
+							op.Add(op.Value(), op.This().Field("qty"), "$$ROOT.bonus", "$$outer"),
+						),
+					),
+			)
+			require.NoError(t, err)
+		},
+	)
+
+	t.Run(
+		"InvalidName",
+		func(t *testing.T) {
+			let := op.LetArg()
+			let.Var("Total", 1)
+
+			_, err := op.Let(let.In(1))
+			require.True(t, errors.Is(err, op.ErrInvalidVariableName))
+		},
+	)
+}
+
+func TestFunc_Field(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"$setField": bson.M{
+				"field": bson.M{"$literal": "price.usd"},
+				"input": "$$ROOT",
+				"value": 100,
+			},
+		},
+		op.SetField("price.usd", "$$ROOT", 100),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"$setField": bson.M{
+				"field": "price",
+				"input": "$$ROOT",
+				"value": 100,
+			},
+		},
+		op.SetField("price", "$$ROOT", 100),
+	)
+
+	require.Equal(t, bson.M{"$getField": "price"}, op.GetField("price", nil))
+	require.Equal(
+		t,
+		bson.M{
+			"$getField": bson.M{
+				"field": bson.M{"$literal": "$price"},
+			},
+		},
+		op.GetField("$price", nil),
+	)
+	require.Equal(
+		t,
+		bson.M{
+			"$getField": bson.M{
+				"field": bson.M{"$literal": "price.usd"},
+				"input": "$item",
+			},
+		},
+		op.GetField("price.usd", "$item"),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"$unsetField": bson.M{
+				"field": bson.M{"$literal": "$price"},
+				"input": "$$ROOT",
+			},
+		},
+		op.UnsetField("$price", "$$ROOT"),
+	)
+}
+
+func TestFunc_SortAndFilterArray(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"$sortArray": bson.M{
+				"input": "$team",
+				"sortBy": bson.D{
+					{Key: "age", Value: -1},
+					{Key: "name", Value: 1},
+				},
+			},
+		},
+		op.SortArray(
+			"$team",
+			sort.SortArg("age", sort.DESC()),
+			sort.SortArg("name", sort.ASC()),
+		),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"$sortArray": bson.M{
+				"input":  "$scores",
+				"sortBy": -1,
+			},
+		},
+		op.SortArrayValues("$scores", sort.DESC()),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"$filter": bson.M{
+				"input": "$items",
+				"as":    "item",
+				"cond":  bson.M{"$gte": bson.A{"$$item.price", 100}},
+				"limit": 1,
+			},
+		},
+		op.Filter("$items", "item", op.GTE("$$item.price", 100), 1),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"$filter": bson.M{
+				"input": "$items",
+				"as":    "this",
+				"cond":  true,
+			},
+		},
+		op.Filter("$items", "", true),
+	)
+}
+
+func TestFunc_IndexOf(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{"$indexOfCP": bson.A{"$slug", "-"}},
+		op.IndexOfCP("$slug", "-"),
+	)
+
+	require.Equal(
+		t,
+		bson.M{"$indexOfBytes": bson.A{"$slug", "-", 2, 10}},
+		op.IndexOfBytes(
+			"$slug",
+			"-",
+			op.IndexOfOptionalParamsArgs().SetStart(2),
+			op.IndexOfOptionalParamsArgs().SetEnd(10),
+		),
+	)
+
+	// start is required by MongoDB when end is set
+	require.Equal(
+		t,
+		bson.M{"$indexOfArray": bson.A{"$items", "x", 0, 5}},
+		op.IndexOfArray("$items", "x", op.IndexOfOptionalParamsArgs().SetEnd(5)),
+	)
+}
+
+func TestFunc_DerivativeAndIntegral(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"$derivative": bson.M{
+				"input": "$miles",
+				"unit":  "hour",
+			},
+		},
+		op.Derivative("$miles", op.UnitHour),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"$integral": bson.M{
+				"input": "$kilowatts",
+			},
+		},
+		op.Integral("$kilowatts"),
+	)
+
+	for _, unit := range []op.TimeUnit{op.UnitYear, op.UnitQuarter, op.UnitMonth} {
+		require.Panics(t, func() { op.Derivative("$miles", unit) }, unit.String())
+		require.Panics(t, func() { op.Integral("$kilowatts", unit) }, unit.String())
+	}
+}
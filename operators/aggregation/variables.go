@@ -0,0 +1,331 @@
+package aggregation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+var (
+	ErrInvalidVariableName = errors.New("invalid variable name")
+	ErrUndefinedVariable   = errors.New("reference to undefined variable")
+)
+
+// Variable Expression Operators
+
+// Handle of the variable, can be used as expression, it's rendered as "$$<name>"
+type VariableRef interface {
+	Name() string
+	// Return "$$<name>"
+	Ref() string
+	// Return "$$<name>.<path>"
+	Field(path string) string
+}
+
+type variableRef string
+
+func (v variableRef) Name() string {
+	return string(v)
+}
+
+func (v variableRef) Ref() string {
+	return "$$" + string(v)
+}
+
+func (v variableRef) Field(path string) string {
+	return v.Ref() + "." + path
+}
+
+func (v variableRef) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(v.Ref())
+}
+
+// Return handle of variable declared by enclosing expression, e.g. as of Map and Filter or let of $lookup
+func Var(name string) VariableRef {
+	return variableRef(name)
+}
+
+// Return handle of "this" variable, the default variable of Map, Filter and the current element of Reduce
+func This() VariableRef {
+	return Var("this")
+}
+
+// Return handle of "value" variable, the cumulative value of Reduce
+func Value() VariableRef {
+	return Var("value")
+}
+
+// User variable names must begin with a lowercase ascii letter or a non-ascii character
+// and contain only letters, digits and underscores
+func validateVariableName(name string) error {
+	first, _ := utf8.DecodeRuneInString(name)
+	if name == "" || !(unicode.IsLower(first) || first > unicode.MaxASCII) {
+		return fmt.Errorf("%w: %q", ErrInvalidVariableName, name)
+	}
+
+	for _, r := range name {
+		if !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return fmt.Errorf("%w: %q", ErrInvalidVariableName, name)
+		}
+	}
+	return nil
+}
+
+type LetArger interface {
+	formatLetArg() (bson.M, error)
+	// Declare variable and return it's handle
+	Var(name string, value interface{}) VariableRef
+	// Declare variables defined outside of $let, e.g. as of enclosing Map or let of $lookup
+	Outer(vars ...VariableRef) LetArger
+	In(expression interface{}) LetArger
+}
+
+type letArg struct {
+	vars  bson.D
+	outer []VariableRef
+	in    interface{}
+}
+
+func (l *letArg) formatLetArg() (bson.M, error) {
+	scope := variableScope{}
+	for _, v := range l.outer {
+		scope[v.Name()] = true
+	}
+
+	vars := bson.M{}
+	for _, v := range l.vars {
+		if err := validateVariableName(v.Key); err != nil {
+			return nil, err
+		}
+
+		// variables can't reference each other in the same $let
+		if err := scope.validate(v.Value); err != nil {
+			return nil, err
+		}
+		vars[v.Key] = v.Value
+	}
+
+	if err := scope.with(l.varNames()...).validate(l.in); err != nil {
+		return nil, err
+	}
+
+	return bson.M{
+		"vars": vars,
+		"in":   l.in,
+	}, nil
+}
+
+func (l *letArg) varNames() (names []string) {
+	for _, v := range l.vars {
+		names = append(names, v.Key)
+	}
+	return names
+}
+
+func (l *letArg) Var(name string, value interface{}) VariableRef {
+	for i := range l.vars {
+		if l.vars[i].Key == name {
+			l.vars[i].Value = value
+			return Var(name)
+		}
+	}
+	l.vars = append(l.vars, bson.E{Key: name, Value: value})
+	return Var(name)
+}
+
+func (l *letArg) Outer(vars ...VariableRef) LetArger {
+	l.outer = append(l.outer, vars...)
+	return l
+}
+
+func (l *letArg) In(expression interface{}) LetArger {
+	l.in = expression
+	return l
+}
+
+func LetArg() LetArger {
+	return &letArg{}
+}
+
+/*
+Binds variables for use in the specified expression, and returns the result of the expression.
+
+	{
+		$let: {
+			vars: { <var1>: <expression>, ... },
+			in: <expression>
+		}
+	}
+
+Return ErrInvalidVariableName if name of variable is invalid
+and ErrUndefinedVariable if expressions reference variable that is not declared.
+Variables of nested Let, Map, Filter and Reduce are in scope of their expressions,
+system variables (names that start with uppercase letter, e.g. $$ROOT) are always in scope.
+*/
+func Let(
+	arg LetArger,
+) (bson.M, error) {
+	let, err := arg.formatLetArg()
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{
+		"$let": let,
+	}, nil
+}
+
+type variableScope map[string]bool
+
+func (s variableScope) with(names ...string) variableScope {
+	scope := variableScope{}
+	for name := range s {
+		scope[name] = true
+	}
+	for _, name := range names {
+		scope[name] = true
+	}
+	return scope
+}
+
+func (s variableScope) validateRef(ref string) error {
+	name := strings.SplitN(strings.TrimPrefix(ref, "$$"), ".", 2)[0]
+	if first, _ := utf8.DecodeRuneInString(name); unicode.IsUpper(first) {
+		return nil
+	}
+
+	if !s[name] {
+		return fmt.Errorf("%w: %s", ErrUndefinedVariable, ref)
+	}
+	return nil
+}
+
+func (s variableScope) validate(expression interface{}) error {
+	switch e := expression.(type) {
+	case nil:
+		return nil
+	case VariableRef:
+		return s.validateRef(e.Ref())
+	case string:
+		if strings.HasPrefix(e, "$$") {
+			return s.validateRef(e)
+		}
+		return nil
+	case bson.D:
+		return s.validateDocument(e)
+	case bson.E:
+		return s.validateDocument(bson.D{e})
+	}
+
+	v := reflect.ValueOf(expression)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+
+		doc := bson.D{}
+		iter := v.MapRange()
+		for iter.Next() {
+			doc = append(doc, bson.E{Key: iter.Key().String(), Value: iter.Value().Interface()})
+		}
+		return s.validateDocument(doc)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if err := s.validate(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s variableScope) validateDocument(doc bson.D) error {
+	for _, e := range doc {
+		var err error
+		switch e.Key {
+		case "$literal":
+		case "$let":
+			err = s.validateLet(e.Value)
+		case "$map", "$filter":
+			err = s.validateIterator(e.Value, []string{"this"}, "in", "cond")
+		case "$reduce":
+			err = s.validateIterator(e.Value, []string{"this", "value"}, "in")
+		default:
+			err = s.validate(e.Value)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return fields of document and true if value is a document
+func documentFields(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case bson.M:
+		return v, true
+	case map[string]interface{}:
+		return v, true
+	case bson.D:
+		return v.Map(), true
+	}
+	return nil, false
+}
+
+func (s variableScope) validateLet(value interface{}) error {
+	let, ok := documentFields(value)
+	if !ok {
+		return s.validate(value)
+	}
+
+	names := []string{}
+	if vars, ok := documentFields(let["vars"]); ok {
+		for name, v := range vars {
+			if err := s.validate(v); err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+	}
+	return s.with(names...).validate(let["in"])
+}
+
+// Validate $map, $filter and $reduce, scoped fields see the variables of current element
+func (s variableScope) validateIterator(value interface{}, names []string, scopedFields ...string) error {
+	fields, ok := documentFields(value)
+	if !ok {
+		return s.validate(value)
+	}
+
+	if as, ok := fields["as"].(string); ok && as != "" {
+		names = []string{as}
+	}
+	scoped := s.with(names...)
+
+	for field, v := range fields {
+		scope := s
+		for _, f := range scopedFields {
+			if f == field {
+				scope = scoped
+			}
+		}
+
+		if err := scope.validate(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package query
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Coordinates is a pair of <longitude, latitude> for GeoJSON objects
+// or <x, y> for legacy coordinate pairs
+type Coordinates [2]float64
+
+func Coord(lng, lat float64) Coordinates {
+	return Coordinates{lng, lat}
+}
+
+func (c Coordinates) array() bson.A {
+	return bson.A{c[0], c[1]}
+}
+
+func coordinatesArray(coords []Coordinates) bson.A {
+	array := bson.A{}
+	for _, c := range coords {
+		array = append(array, c.array())
+	}
+	return array
+}
+
+// GeoShape is any shape that can be used with $geoWithin
+type GeoShape interface {
+	formatGeoShape() bson.M
+}
+
+// GeoJSONGeometry is a GeoJSON object that can be used with $geoWithin, $geoIntersects
+type GeoJSONGeometry interface {
+	GeoShape
+	// return { type: <GeoJSON type>, coordinates: <coordinates> }
+	GeoJSON() bson.M
+}
+
+// GeoJSONPoint is a GeoJSON object with type Point that can be used with $near and $nearSphere
+type GeoJSONPoint interface {
+	GeoJSONGeometry
+	geoJSONPoint()
+}
+
+// GeoJSONPolygon is a GeoJSON object with type Polygon, it can be part of MultiPolygon
+type GeoJSONPolygon interface {
+	GeoJSONGeometry
+	polygonRings() bson.A
+}
+
+type geoJSONGeometry struct {
+	Type        string
+	Coordinates bson.A
+}
+
+func (g geoJSONGeometry) GeoJSON() bson.M {
+	return bson.M{
+		"type":        g.Type,
+		"coordinates": g.Coordinates,
+	}
+}
+
+func (g geoJSONGeometry) formatGeoShape() bson.M {
+	return bson.M{"$geometry": g.GeoJSON()}
+}
+
+type geoJSONPoint struct {
+	geoJSONGeometry
+}
+
+func (geoJSONPoint) geoJSONPoint() {}
+
+/*
+return
+	{ type: "Point", coordinates: [ <longitude>, <latitude> ] }
+*/
+func GeoPoint(lng, lat float64) GeoJSONPoint {
+	return geoJSONPoint{
+		geoJSONGeometry{
+			Type:        "Point",
+			Coordinates: Coord(lng, lat).array(),
+		},
+	}
+}
+
+type geoJSONPolygon struct {
+	geoJSONGeometry
+}
+
+func (g geoJSONPolygon) polygonRings() bson.A {
+	return g.Coordinates
+}
+
+/*
+return
+	{ type: "LineString", coordinates: [ [ <lng1>, <lat1> ], [ <lng2>, <lat2> ], ... ] }
+*/
+func GeoLineString(points ...Coordinates) GeoJSONGeometry {
+	return geoJSONGeometry{
+		Type:        "LineString",
+		Coordinates: coordinatesArray(points),
+	}
+}
+
+/*
+Each ring is an array of positions, the first and the last positions of a ring must be equivalent.
+The first ring is the exterior ring, others are interior rings.
+
+return
+	{ type: "Polygon", coordinates: [ <ring1>, <ring2>, ... ] }
+*/
+func GeoPolygon(rings ...[]Coordinates) GeoJSONPolygon {
+	array := bson.A{}
+	for _, ring := range rings {
+		array = append(array, coordinatesArray(ring))
+	}
+
+	return geoJSONPolygon{
+		geoJSONGeometry{
+			Type:        "Polygon",
+			Coordinates: array,
+		},
+	}
+}
+
+/*
+return
+	{ type: "MultiPolygon", coordinates: [ <polygon1 rings>, <polygon2 rings>, ... ] }
+*/
+func GeoMultiPolygon(polygons ...GeoJSONPolygon) GeoJSONGeometry {
+	array := bson.A{}
+	for _, polygon := range polygons {
+		array = append(array, polygon.polygonRings())
+	}
+
+	return geoJSONGeometry{
+		Type:        "MultiPolygon",
+		Coordinates: array,
+	}
+}
+
+// Legacy coordinate pairs shapes
+
+type legacyShape struct {
+	op    string
+	value bson.A
+}
+
+func (l legacyShape) formatGeoShape() bson.M {
+	return bson.M{l.op: l.value}
+}
+
+/*
+return
+	{ $box: [ [ <bottom left coordinates> ], [ <upper right coordinates> ] ] }
+*/
+func BoxShape(bottomLeft, upperRight Coordinates) GeoShape {
+	return legacyShape{
+		op:    "$box",
+		value: bson.A{bottomLeft.array(), upperRight.array()},
+	}
+}
+
+/*
+radius measured in the units used by the coordinate system
+
+return
+	{ $center: [ [ <x>, <y> ] , <radius> ] }
+*/
+func CenterShape(center Coordinates, radius float64) GeoShape {
+	return legacyShape{
+		op:    "$center",
+		value: bson.A{center.array(), radius},
+	}
+}
+
+/*
+radius measured in radians
+
+return
+	{ $centerSphere: [ [ <x>, <y> ], <radius> ] }
+*/
+func CenterSphereShape(center Coordinates, radius float64) GeoShape {
+	return legacyShape{
+		op:    "$centerSphere",
+		value: bson.A{center.array(), radius},
+	}
+}
+
+/*
+return
+	{ $polygon: [ [ <x1> , <y1> ], [ <x2> , <y2> ], [ <x3> , <y3> ], ... ] }
+*/
+func PolygonShape(points ...Coordinates) GeoShape {
+	return legacyShape{
+		op:    "$polygon",
+		value: coordinatesArray(points),
+	}
+}
@@ -0,0 +1,211 @@
+package query
+
+import (
+	"github.com/0B1t322/MongoBuilder/operators/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type JsonSchemaArger interface {
+	formatJsonSchemaArg() bson.M
+	// If only one type set bsonType: <BSON type> else bsonType: [ <BSON type1>, <BSON type2>, ... ]
+	BsonType(types ...types.Type) JsonSchemaArger
+	Required(fields ...string) JsonSchemaArger
+	Property(field string, schema JsonSchemaArger) JsonSchemaArger
+	// If only one schema set items: <schema> else items: [ <schema1>, <schema2>, ... ]
+	Items(schemas ...JsonSchemaArger) JsonSchemaArger
+	Enum(values ...interface{}) JsonSchemaArger
+	Pattern(pattern string) JsonSchemaArger
+	Minimum(value interface{}) JsonSchemaArger
+	ExclusiveMinimum(value bool) JsonSchemaArger
+	Maximum(value interface{}) JsonSchemaArger
+	ExclusiveMaximum(value bool) JsonSchemaArger
+	MinLength(length int64) JsonSchemaArger
+	MaxLength(length int64) JsonSchemaArger
+	MinItems(count int64) JsonSchemaArger
+	MaxItems(count int64) JsonSchemaArger
+	UniqueItems(value bool) JsonSchemaArger
+	MinProperties(count int64) JsonSchemaArger
+	MaxProperties(count int64) JsonSchemaArger
+	AdditionalProperties(allowed bool) JsonSchemaArger
+	AdditionalPropertiesSchema(schema JsonSchemaArger) JsonSchemaArger
+	OneOf(schemas ...JsonSchemaArger) JsonSchemaArger
+	AnyOf(schemas ...JsonSchemaArger) JsonSchemaArger
+	AllOf(schemas ...JsonSchemaArger) JsonSchemaArger
+	Not(schema JsonSchemaArger) JsonSchemaArger
+	Title(title string) JsonSchemaArger
+	Description(description string) JsonSchemaArger
+}
+
+type jsonSchemaArg struct {
+	schema     bson.M
+	required   []string
+	properties bson.M
+}
+
+func (j *jsonSchemaArg) formatJsonSchemaArg() bson.M {
+	b := bson.M{}
+	{
+		for k, v := range j.schema {
+			b[k] = v
+		}
+
+		if len(j.required) > 0 {
+			required := bson.A{}
+			for _, field := range j.required {
+				required = append(required, field)
+			}
+			b["required"] = required
+		}
+
+		if len(j.properties) > 0 {
+			b["properties"] = j.properties
+		}
+	}
+	return b
+}
+
+func formatJsonSchemaArgs(schemas ...JsonSchemaArger) bson.A {
+	array := bson.A{}
+	for _, schema := range schemas {
+		array = append(array, schema.formatJsonSchemaArg())
+	}
+	return array
+}
+
+func (j *jsonSchemaArg) set(key string, value interface{}) JsonSchemaArger {
+	j.schema[key] = value
+	return j
+}
+
+func (j *jsonSchemaArg) BsonType(types ...types.Type) JsonSchemaArger {
+	if len(types) == 1 {
+		return j.set("bsonType", types[0].StringIdentifier())
+	}
+
+	typesArray := bson.A{}
+	for _, t := range types {
+		typesArray = append(typesArray, t.StringIdentifier())
+	}
+	return j.set("bsonType", typesArray)
+}
+
+func (j *jsonSchemaArg) Required(fields ...string) JsonSchemaArger {
+	for _, field := range fields {
+		if !j.isRequired(field) {
+			j.required = append(j.required, field)
+		}
+	}
+	return j
+}
+
+func (j *jsonSchemaArg) isRequired(field string) bool {
+	for _, required := range j.required {
+		if required == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *jsonSchemaArg) Property(field string, schema JsonSchemaArger) JsonSchemaArger {
+	j.properties[field] = schema.formatJsonSchemaArg()
+	return j
+}
+
+func (j *jsonSchemaArg) Items(schemas ...JsonSchemaArger) JsonSchemaArger {
+	if len(schemas) == 1 {
+		return j.set("items", schemas[0].formatJsonSchemaArg())
+	}
+	return j.set("items", formatJsonSchemaArgs(schemas...))
+}
+
+func (j *jsonSchemaArg) Enum(values ...interface{}) JsonSchemaArger {
+	return j.set("enum", bson.A(values))
+}
+
+func (j *jsonSchemaArg) Pattern(pattern string) JsonSchemaArger {
+	return j.set("pattern", pattern)
+}
+
+func (j *jsonSchemaArg) Minimum(value interface{}) JsonSchemaArger {
+	return j.set("minimum", value)
+}
+
+func (j *jsonSchemaArg) ExclusiveMinimum(value bool) JsonSchemaArger {
+	return j.set("exclusiveMinimum", value)
+}
+
+func (j *jsonSchemaArg) Maximum(value interface{}) JsonSchemaArger {
+	return j.set("maximum", value)
+}
+
+func (j *jsonSchemaArg) ExclusiveMaximum(value bool) JsonSchemaArger {
+	return j.set("exclusiveMaximum", value)
+}
+
+func (j *jsonSchemaArg) MinLength(length int64) JsonSchemaArger {
+	return j.set("minLength", length)
+}
+
+func (j *jsonSchemaArg) MaxLength(length int64) JsonSchemaArger {
+	return j.set("maxLength", length)
+}
+
+func (j *jsonSchemaArg) MinItems(count int64) JsonSchemaArger {
+	return j.set("minItems", count)
+}
+
+func (j *jsonSchemaArg) MaxItems(count int64) JsonSchemaArger {
+	return j.set("maxItems", count)
+}
+
+func (j *jsonSchemaArg) UniqueItems(value bool) JsonSchemaArger {
+	return j.set("uniqueItems", value)
+}
+
+func (j *jsonSchemaArg) MinProperties(count int64) JsonSchemaArger {
+	return j.set("minProperties", count)
+}
+
+func (j *jsonSchemaArg) MaxProperties(count int64) JsonSchemaArger {
+	return j.set("maxProperties", count)
+}
+
+func (j *jsonSchemaArg) AdditionalProperties(allowed bool) JsonSchemaArger {
+	return j.set("additionalProperties", allowed)
+}
+
+func (j *jsonSchemaArg) AdditionalPropertiesSchema(schema JsonSchemaArger) JsonSchemaArger {
+	return j.set("additionalProperties", schema.formatJsonSchemaArg())
+}
+
+func (j *jsonSchemaArg) OneOf(schemas ...JsonSchemaArger) JsonSchemaArger {
+	return j.set("oneOf", formatJsonSchemaArgs(schemas...))
+}
+
+func (j *jsonSchemaArg) AnyOf(schemas ...JsonSchemaArger) JsonSchemaArger {
+	return j.set("anyOf", formatJsonSchemaArgs(schemas...))
+}
+
+func (j *jsonSchemaArg) AllOf(schemas ...JsonSchemaArger) JsonSchemaArger {
+	return j.set("allOf", formatJsonSchemaArgs(schemas...))
+}
+
+func (j *jsonSchemaArg) Not(schema JsonSchemaArger) JsonSchemaArger {
+	return j.set("not", schema.formatJsonSchemaArg())
+}
+
+func (j *jsonSchemaArg) Title(title string) JsonSchemaArger {
+	return j.set("title", title)
+}
+
+func (j *jsonSchemaArg) Description(description string) JsonSchemaArger {
+	return j.set("description", description)
+}
+
+func JsonSchemaArg() JsonSchemaArger {
+	return &jsonSchemaArg{
+		schema:     bson.M{},
+		properties: bson.M{},
+	}
+}
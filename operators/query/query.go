@@ -1,10 +1,13 @@
 package query
 
 import (
+	"fmt"
+
 	"github.com/0B1t322/MongoBuilder/operators/options"
 	"github.com/0B1t322/MongoBuilder/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func singleOper(op string, value interface{}) bson.M {
@@ -152,8 +155,18 @@ func Expr(aggragateExpression bson.M) bson.M {
 	return bson.M{"$expr": aggragateExpression}
 }
 
-// TODO
-// func JsonSchema()
+/*
+Matches documents that satisfy the specified JSON Schema.
+
+return
+	{ $jsonSchema: <JSON Schema object> }
+
+The same document can be used as a collection validator:
+	options.CreateCollection().SetValidator(query.JsonSchema(schema))
+*/
+func JsonSchema(schema JsonSchemaArger) bson.M {
+	return bson.M{"$jsonSchema": schema.formatJsonSchemaArg()}
+}
 
 // return { field: { $mod: [ divisor, remainder ] } }
 func Mod(field string, divisor, remainder float64) bson.M {
@@ -203,12 +216,201 @@ func Text(search string, language string, caseSensitive, diacriticSensitive bool
 	}
 }
 
-// TODO
-// func Where()
+/*
+Use the $where operator to pass either a string containing a JavaScript expression or a full JavaScript function to the query system.
+
+return
+	{ $where: <string> }
+*/
+func Where(code string) bson.M {
+	return bson.M{"$where": code}
+}
+
+/*
+Work like Where but with primitive.JavaScript
+
+return
+	{ $where: <JavaScript Code> }
+*/
+func WhereJavaScript(code primitive.JavaScript) bson.M {
+	return bson.M{"$where": code}
+}
+
+// Miscellaneous
+
+/*
+The $comment query operator associates a comment to any expression taking a query predicate.
+
+return
+	{ $comment: <comment> }
+*/
+func Comment(comment string) bson.M {
+	return bson.M{"$comment": comment}
+}
+
+/*
+Attach comment to filter, filter can be any query, for example built with And or Or
+
+return
+	{ <query>, $comment: <comment> }
+*/
+func WithComment(filter bson.M, comment string) bson.M {
+	return utils.MergeBsonM(filter, Comment(comment))
+}
 
 // Geospatial
 
-// TODO all Geospatial
+/*
+Selects documents with geospatial data that exists entirely within a specified shape.
+
+If shape is GeoJSON geometry return
+	{ <field>: { $geoWithin: { $geometry: { type: <GeoJSON type>, coordinates: [ <coordinates> ] } } } }
+If shape is legacy shape return
+	{ <field>: { $geoWithin: { <shape operator>: <coordinates> } } }
+*/
+func GeoWithin(field string, shape GeoShape) bson.M {
+	return bson.M{field: bson.M{"$geoWithin": shape.formatGeoShape()}}
+}
+
+/*
+Selects documents whose geospatial data intersects with a specified GeoJSON object.
+
+return
+	{ <field>: { $geoIntersects: { $geometry: { type: <GeoJSON type>, coordinates: [ <coordinates> ] } } } }
+*/
+func GeoIntersects(field string, geometry GeoJSONGeometry) bson.M {
+	return bson.M{field: bson.M{"$geoIntersects": geometry.formatGeoShape()}}
+}
+
+type NearOptionalsArger interface {
+	formatNearOptionals() bson.M
+	MaxDistance(distance float64) NearOptionalsArger
+	MinDistance(distance float64) NearOptionalsArger
+}
+
+type nearOptionals struct {
+	maxDistance *float64
+	minDistance *float64
+}
+
+func (n nearOptionals) formatNearOptionals() bson.M {
+	b := bson.M{}
+	{
+		if n.maxDistance != nil {
+			b["$maxDistance"] = *n.maxDistance
+		}
+		if n.minDistance != nil {
+			b["$minDistance"] = *n.minDistance
+		}
+	}
+	return b
+}
+
+func (n nearOptionals) MaxDistance(distance float64) NearOptionalsArger {
+	n.maxDistance = &distance
+	return n
+}
+
+func (n nearOptionals) MinDistance(distance float64) NearOptionalsArger {
+	n.minDistance = &distance
+	return n
+}
+
+func NearOptionalsArg() NearOptionalsArger {
+	return nearOptionals{}
+}
+
+func mergeNearOptionals(opts ...NearOptionalsArger) bson.M {
+	return utils.MergeBsonM(
+		func() (slice []bson.M) {
+			for _, opt := range opts {
+				slice = append(slice, opt.formatNearOptionals())
+			}
+			return slice
+		}()...,
+	)
+}
+
+func near(op string, field string, point GeoJSONPoint, opts ...NearOptionalsArger) bson.M {
+	return bson.M{
+		field: bson.M{
+			op: utils.MergeBsonM(
+				point.formatGeoShape(),
+				mergeNearOptionals(opts...),
+			),
+		},
+	}
+}
+
+func nearLegacy(op string, field string, point Coordinates, opts ...NearOptionalsArger) bson.M {
+	return bson.M{
+		field: utils.MergeBsonM(
+			bson.M{op: point.array()},
+			mergeNearOptionals(opts...),
+		),
+	}
+}
+
+/*
+Specifies a point for which a geospatial query returns the documents from nearest to farthest.
+Distances are in meters.
+
+return
+	{
+		<field>: {
+			$near: {
+				$geometry: { type: "Point", coordinates: [ <longitude> , <latitude> ] },
+				$maxDistance: <distance in meters>,
+				$minDistance: <distance in meters>
+			}
+		}
+	}
+*/
+func Near(field string, point GeoJSONPoint, opts ...NearOptionalsArger) bson.M {
+	return near("$near", field, point, opts...)
+}
+
+/*
+Work like Near but for legacy coordinate pairs.
+Distances are in the units used by the coordinate system.
+
+return
+	{ <field>: { $near: [ <x>, <y> ], $maxDistance: <distance> } }
+*/
+func NearLegacy(field string, point Coordinates, opts ...NearOptionalsArger) bson.M {
+	return nearLegacy("$near", field, point, opts...)
+}
+
+/*
+Specifies a point for which a geospatial query returns the documents from nearest to farthest.
+MongoDB calculates distances for $nearSphere using spherical geometry.
+Distances are in meters.
+
+return
+	{
+		<field>: {
+			$nearSphere: {
+				$geometry: { type: "Point", coordinates: [ <longitude> , <latitude> ] },
+				$maxDistance: <distance in meters>,
+				$minDistance: <distance in meters>
+			}
+		}
+	}
+*/
+func NearSphere(field string, point GeoJSONPoint, opts ...NearOptionalsArger) bson.M {
+	return near("$nearSphere", field, point, opts...)
+}
+
+/*
+Work like NearSphere but for legacy coordinate pairs.
+Distances are in radians.
+
+return
+	{ <field>: { $nearSphere: [ <x>, <y> ], $minDistance: <distance>, $maxDistance: <distance> } }
+*/
+func NearSphereLegacy(field string, point Coordinates, opts ...NearOptionalsArger) bson.M {
+	return nearLegacy("$nearSphere", field, point, opts...)
+}
 
 
 // Array
@@ -243,6 +445,104 @@ func Size(field string, size int) bson.M {
 	return bson.M{field: singleOper("$size", size)}
 }
 
+// Bitwise
+
+type BitMask interface {
+	getBitMask() interface{}
+}
+
+type bitMask struct {
+	mask interface{}
+}
+
+func (b bitMask) getBitMask() interface{} {
+	return b.mask
+}
+
+// Bit positions mask, position of the least significant bit is 0
+// Panic if any position is negative
+func BitPositions(positions ...int) bitMask {
+	array := bson.A{}
+	for _, position := range positions {
+		if position < 0 {
+			panic(fmt.Sprintf("bit position must be non-negative, got %d", position))
+		}
+		array = append(array, position)
+	}
+	return bitMask{mask: array}
+}
+
+// Numeric bitmask
+// Panic if mask is negative
+func BitMaskNumber(mask int32) bitMask {
+	if mask < 0 {
+		panic(fmt.Sprintf("numeric bitmask must be non-negative, got %d", mask))
+	}
+	return bitMask{mask: mask}
+}
+
+// BinData bitmask, data is a binary with subtype 0
+func BitMaskBinData(data []byte) bitMask {
+	return bitMask{
+		mask: primitive.Binary{
+			Subtype: bsontype.BinaryGeneric,
+			Data:    data,
+		},
+	}
+}
+
+/*
+Matches numeric or binary values in which a set of bit positions all have a value of 1.
+
+{ <field>: { $bitsAllSet: <numeric bitmask> } }
+
+{ <field>: { $bitsAllSet: <BinData bitmask> } }
+
+{ <field>: { $bitsAllSet: [ <position1>, <position2>, ... ] } }
+*/
+func BitsAllSet(field string, mask BitMask) bson.M {
+	return bson.M{field: singleOper("$bitsAllSet", mask.getBitMask())}
+}
+
+/*
+Matches numeric or binary values in which any bit from a set of bit positions has a value of 1.
+
+{ <field>: { $bitsAnySet: <numeric bitmask> } }
+
+{ <field>: { $bitsAnySet: <BinData bitmask> } }
+
+{ <field>: { $bitsAnySet: [ <position1>, <position2>, ... ] } }
+*/
+func BitsAnySet(field string, mask BitMask) bson.M {
+	return bson.M{field: singleOper("$bitsAnySet", mask.getBitMask())}
+}
+
+/*
+Matches numeric or binary values in which a set of bit positions all have a value of 0.
+
+{ <field>: { $bitsAllClear: <numeric bitmask> } }
+
+{ <field>: { $bitsAllClear: <BinData bitmask> } }
+
+{ <field>: { $bitsAllClear: [ <position1>, <position2>, ... ] } }
+*/
+func BitsAllClear(field string, mask BitMask) bson.M {
+	return bson.M{field: singleOper("$bitsAllClear", mask.getBitMask())}
+}
+
+/*
+Matches numeric or binary values in which any bit from a set of bit positions has a value of 0.
+
+{ <field>: { $bitsAnyClear: <numeric bitmask> } }
+
+{ <field>: { $bitsAnyClear: <BinData bitmask> } }
+
+{ <field>: { $bitsAnyClear: [ <position1>, <position2>, ... ] } }
+*/
+func BitsAnyClear(field string, mask BitMask) bson.M {
+	return bson.M{field: singleOper("$bitsAnyClear", mask.getBitMask())}
+}
+
 type EQFieldArger interface {
 	formatEQFieldArg() bson.M
 }
@@ -277,6 +577,25 @@ func EQFieldArgWithAnothers(field string, anothers ...eqFieldArg) eqFieldArg {
 	}
 }
 
+/*
+Work like EQFieldArgWithAnothers but value is bson.D that keep the order of fields.
+Use it for exact match of embedded document because the order of fields is matter:
+
+	{ <field>: { <another1 field>: <another1 value>, <another2 field>: <another2 value>, ... } }
+*/
+func EQFieldArgWithAnothersOrdered(field string, anothers ...eqFieldArg) eqFieldArg {
+	return eqFieldArg{
+		Field: field,
+		Value: func() (d bson.D) {
+			d = bson.D{}
+			for _, another := range anothers {
+				d = append(d, bson.E{Key: another.Field, Value: another.Value})
+			}
+			return d
+		}(),
+	}
+}
+
 func EQFields(args ...EQFieldArger) bson.M {
 	return utils.MergeBsonM(
 		func () (slice []bson.M) {
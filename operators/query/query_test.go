@@ -0,0 +1,393 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/0B1t322/MongoBuilder/operators/query"
+	"github.com/0B1t322/MongoBuilder/operators/types"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFunc_Geospatial(t *testing.T) {
+	t.Run(
+		"GeoWithin",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"loc": bson.M{
+						"$geoWithin": bson.M{
+							"$geometry": bson.M{
+								"type": "Polygon",
+								"coordinates": bson.A{
+									bson.A{
+										bson.A{0.0, 0.0},
+										bson.A{3.0, 6.0},
+										bson.A{6.0, 1.0},
+										bson.A{0.0, 0.0},
+									},
+								},
+							},
+						},
+					},
+				},
+				query.GeoWithin(
+					"loc",
+					query.GeoPolygon(
+						[]query.Coordinates{
+							query.Coord(0, 0),
+							query.Coord(3, 6),
+							query.Coord(6, 1),
+							query.Coord(0, 0),
+						},
+					),
+				),
+			)
+
+			var (
+				triangle query.GeoJSONPolygon = query.GeoPolygon(
+					[]query.Coordinates{
+						query.Coord(0, 0),
+						query.Coord(3, 6),
+						query.Coord(6, 1),
+						query.Coord(0, 0),
+					},
+				)
+				square = query.GeoPolygon(
+					[]query.Coordinates{
+						query.Coord(10, 10),
+						query.Coord(10, 20),
+						query.Coord(20, 20),
+						query.Coord(20, 10),
+						query.Coord(10, 10),
+					},
+				)
+			)
+			require.Equal(
+				t,
+				bson.M{
+					"loc": bson.M{
+						"$geoWithin": bson.M{
+							"$geometry": bson.M{
+								"type": "MultiPolygon",
+								"coordinates": bson.A{
+									bson.A{
+										bson.A{
+											bson.A{0.0, 0.0},
+											bson.A{3.0, 6.0},
+											bson.A{6.0, 1.0},
+											bson.A{0.0, 0.0},
+										},
+									},
+									bson.A{
+										bson.A{
+											bson.A{10.0, 10.0},
+											bson.A{10.0, 20.0},
+											bson.A{20.0, 20.0},
+											bson.A{20.0, 10.0},
+											bson.A{10.0, 10.0},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				query.GeoWithin("loc", query.GeoMultiPolygon(triangle, square)),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"loc": bson.M{
+						"$geoWithin": bson.M{
+							"$centerSphere": bson.A{
+								bson.A{-88.0, 30.0},
+								10 / 3963.2,
+							},
+						},
+					},
+				},
+				query.GeoWithin(
+					"loc",
+					query.CenterSphereShape(query.Coord(-88, 30), 10/3963.2),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"loc": bson.M{
+						"$geoWithin": bson.M{
+							"$box": bson.A{
+								bson.A{0.0, 0.0},
+								bson.A{100.0, 100.0},
+							},
+						},
+					},
+				},
+				query.GeoWithin(
+					"loc",
+					query.BoxShape(query.Coord(0, 0), query.Coord(100, 100)),
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"GeoIntersects",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"loc": bson.M{
+						"$geoIntersects": bson.M{
+							"$geometry": bson.M{
+								"type": "LineString",
+								"coordinates": bson.A{
+									bson.A{1.0, 2.0},
+									bson.A{3.0, 4.0},
+								},
+							},
+						},
+					},
+				},
+				query.GeoIntersects(
+					"loc",
+					query.GeoLineString(query.Coord(1, 2), query.Coord(3, 4)),
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"Near",
+		func(t *testing.T) {
+			require.Equal(
+				t,
+				bson.M{
+					"$and": bson.A{
+						bson.M{
+							"location": bson.M{
+								"$near": bson.M{
+									"$geometry": bson.M{
+										"type":        "Point",
+										"coordinates": bson.A{-73.9667, 40.78},
+									},
+									"$minDistance": 1000.0,
+									"$maxDistance": 5000.0,
+								},
+							},
+						},
+						bson.M{
+							"category": "Parks",
+						},
+					},
+				},
+				query.And(
+					query.Near(
+						"location",
+						query.GeoPoint(-73.9667, 40.78),
+						query.NearOptionalsArg().
+							MinDistance(1000).
+							MaxDistance(5000),
+					),
+					query.EQField("category", "Parks"),
+				),
+			)
+
+			require.Equal(
+				t,
+				bson.M{
+					"location": bson.M{
+						"$nearSphere":  bson.A{-73.9667, 40.78},
+						"$maxDistance": 0.1,
+					},
+				},
+				query.NearSphereLegacy(
+					"location",
+					query.Coord(-73.9667, 40.78),
+					query.NearOptionalsArg().MaxDistance(0.1),
+				),
+			)
+		},
+	)
+}
+
+func TestFunc_JsonSchema(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"$jsonSchema": bson.M{
+				"bsonType": "object",
+				"required": bson.A{"name", "year"},
+				"properties": bson.M{
+					"name": bson.M{
+						"bsonType":    "string",
+						"description": "must be a string and is required",
+					},
+					"year": bson.M{
+						"bsonType": "int",
+						"minimum":  2017,
+						"maximum":  3017,
+					},
+					"gpa": bson.M{
+						"bsonType": bson.A{"double", "null"},
+					},
+					"tags": bson.M{
+						"bsonType": "array",
+						"items": bson.M{
+							"enum": bson.A{"a", "b"},
+						},
+						"uniqueItems": true,
+					},
+					"email": bson.M{
+						"oneOf": bson.A{
+							bson.M{"bsonType": "string", "pattern": "@mongodb\\.com$"},
+							bson.M{"bsonType": "null"},
+						},
+					},
+				},
+				"additionalProperties": false,
+			},
+		},
+		query.JsonSchema(
+			query.JsonSchemaArg().
+				BsonType(types.Object).
+				Required("name", "year", "name").
+				Property(
+					"name",
+					query.JsonSchemaArg().
+						BsonType(types.String).
+						Description("must be a string and is required"),
+				).
+				Property(
+					"year",
+					query.JsonSchemaArg().
+						BsonType(types.Int32).
+						Minimum(2017).
+						Maximum(3017),
+				).
+				Property(
+					"gpa",
+					query.JsonSchemaArg().BsonType(types.Dobule, types.Null),
+				).
+				Property(
+					"tags",
+					query.JsonSchemaArg().
+						BsonType(types.Array).
+						Items(query.JsonSchemaArg().Enum("a", "b")).
+						UniqueItems(true),
+				).
+				Property(
+					"email",
+					query.JsonSchemaArg().
+						OneOf(
+							query.JsonSchemaArg().BsonType(types.String).Pattern("@mongodb\\.com$"),
+							query.JsonSchemaArg().BsonType(types.Null),
+						),
+				).
+				AdditionalProperties(false),
+		),
+	)
+}
+
+func TestFunc_Bitwise(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"a": bson.M{
+				"$bitsAllSet": bson.A{1, 5},
+			},
+		},
+		query.BitsAllSet("a", query.BitPositions(1, 5)),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"a": bson.M{
+				"$bitsAnySet": int32(35),
+			},
+		},
+		query.BitsAnySet("a", query.BitMaskNumber(35)),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"a": bson.M{
+				"$bitsAllClear": primitive.Binary{Subtype: 0, Data: []byte{0x20}},
+			},
+		},
+		query.BitsAllClear("a", query.BitMaskBinData([]byte{0x20})),
+	)
+
+	require.Panics(
+		t,
+		func() {
+			query.BitsAnyClear("a", query.BitPositions(1, -5))
+		},
+	)
+}
+
+func TestFunc_Where(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"$where": "this.credits == this.debits",
+		},
+		query.Where("this.credits == this.debits"),
+	)
+
+	require.Equal(
+		t,
+		bson.M{
+			"$where": primitive.JavaScript("function() { return this.a > 1 }"),
+		},
+		query.WhereJavaScript("function() { return this.a > 1 }"),
+	)
+}
+
+func TestFunc_Comment(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"$or": bson.A{
+				bson.M{"a": 1},
+				bson.M{"b": 2},
+			},
+			"$comment": "orders-list",
+		},
+		query.WithComment(
+			query.Or(
+				query.EQField("a", 1),
+				query.EQField("b", 2),
+			),
+			"orders-list",
+		),
+	)
+}
+
+func TestFunc_EQFields(t *testing.T) {
+	require.Equal(
+		t,
+		bson.M{
+			"size": bson.D{
+				{Key: "h", Value: 14},
+				{Key: "w", Value: 21},
+				{Key: "uom", Value: "cm"},
+			},
+		},
+		query.EQFields(
+			query.EQFieldArgWithAnothersOrdered(
+				"size",
+				query.EQFieldArg("h", 14),
+				query.EQFieldArg("w", 21),
+				query.EQFieldArg("uom", "cm"),
+			),
+		),
+	)
+}
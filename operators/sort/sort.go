@@ -26,6 +26,11 @@ func DESC() SortOrder {
 	return descOrder{}
 }
 
+// return 1 for ASC and -1 for DESC
+func OrderValue(order SortOrder) int {
+	return order.getOrder()
+}
+
 // return
 // 	{$sort: <sort-order>}
 func SingleSort(order SortOrder) bson.M {
@@ -52,15 +57,22 @@ func SortArg(field string, order SortOrder) sortArg {
 	}
 }
 
+// return
+// 	{ <field1>: <sort order>, <field2>: <sort order> ... }
+// Can be used as index keys or sortBy of operators
+func Spec(args ...SortArger) bson.D {
+	return bson.D(
+		func() (slice []bson.E) {
+			for _, arg := range args {
+				slice = append(slice, arg.formatSortArg())
+			}
+			return slice
+		}(),
+	)
+}
+
 func Sort(args ...SortArger) bson.M {
 	return bson.M{
-		"$sort": bson.D(
-			func() (slice []bson.E) {
-				for _, arg := range args {
-					slice = append(slice, arg.formatSortArg())
-				}
-				return slice
-			}(),
-		),
+		"$sort": Spec(args...),
 	}
 }
\ No newline at end of file
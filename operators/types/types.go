@@ -61,7 +61,7 @@ func (t typeBase) StringIdentifier() string {
 		"timestamp",
 		"long",
 		"decimal",
-	}[t]
+	}[t-1]
 }
 
 func (t typeBase) NumericIdentifier() int8 {
@@ -0,0 +1,144 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrInvalidIdentifier      = errors.New("identifier must begin with a lowercase letter and contain only alphanumeric characters")
+	ErrInvalidArrayFilter     = errors.New("array filter field must be the identifier or begin with identifier")
+	ErrConflictingArrayFilter = errors.New("identifier is used with different array filters")
+)
+
+type PathBuilder interface {
+	// Append field using dot notation
+	Field(field string) PathBuilder
+	// Append "$", update the first element that matches the query
+	Positional() PathBuilder
+	// Append "$[]", update all elements of array
+	AllPositional() PathBuilder
+	/*
+		Append "$[<identifier>]", update all elements that match the filter.
+		Fields of filter must be the identifier or begin with "<identifier>.", e.g.
+			Path("grades").FilteredPositional("elem", query.GTE("elem.grade", 85))
+	*/
+	FilteredPositional(identifier string, filter bson.M) PathBuilder
+	// Return the path that can be used as field of SetArg, IncArg, UnsetArg, PullArg, etc.
+	String() string
+
+	getArrayFilters() []arrayFilter
+}
+
+type arrayFilter struct {
+	identifier string
+	filter     bson.M
+}
+
+// Methods return a new path, so paths with the same prefix don't affect each other
+type path struct {
+	parts   []string
+	filters []arrayFilter
+}
+
+func (p path) Field(field string) PathBuilder {
+	p.parts = append(append([]string{}, p.parts...), field)
+	return p
+}
+
+func (p path) Positional() PathBuilder {
+	return p.Field("$")
+}
+
+func (p path) AllPositional() PathBuilder {
+	return p.Field("$[]")
+}
+
+func (p path) FilteredPositional(identifier string, filter bson.M) PathBuilder {
+	p.filters = append(append([]arrayFilter{}, p.filters...), arrayFilter{identifier: identifier, filter: filter})
+	return p.Field("$[" + identifier + "]")
+}
+
+func (p path) String() string {
+	return strings.Join(p.parts, ".")
+}
+
+func (p path) getArrayFilters() []arrayFilter {
+	return p.filters
+}
+
+func Path(field string) PathBuilder {
+	return path{parts: []string{field}}
+}
+
+func validateIdentifier(identifier string) error {
+	if identifier == "" || !unicode.IsLower(rune(identifier[0])) {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, identifier)
+	}
+
+	for _, r := range identifier {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, identifier)
+		}
+	}
+	return nil
+}
+
+// Fields that begin with "$" are logical operators like $or and are not validated
+func validateArrayFilter(f arrayFilter) error {
+	for field := range f.filter {
+		if strings.HasPrefix(field, "$") {
+			continue
+		}
+
+		if field != f.identifier && !strings.HasPrefix(field, f.identifier+".") {
+			return fmt.Errorf("%w: %s in filter of %s", ErrInvalidArrayFilter, field, f.identifier)
+		}
+	}
+	return nil
+}
+
+/*
+Collect filters of paths to use with options.Update().SetArrayFilters.
+
+Each identifier has exactly one filter, paths can share the identifier only with equal filters.
+Return ErrInvalidIdentifier if identifier is invalid,
+ErrInvalidArrayFilter if filter field don't reference the identifier
+and ErrConflictingArrayFilter if identifier is used with different filters.
+*/
+func ArrayFilters(paths ...PathBuilder) (options.ArrayFilters, error) {
+	identifiers := []string{}
+	filters := map[string]bson.M{}
+	for _, p := range paths {
+		for _, f := range p.getArrayFilters() {
+			if err := validateIdentifier(f.identifier); err != nil {
+				return options.ArrayFilters{}, err
+			}
+
+			if err := validateArrayFilter(f); err != nil {
+				return options.ArrayFilters{}, err
+			}
+
+			if filter, find := filters[f.identifier]; find {
+				if !reflect.DeepEqual(filter, f.filter) {
+					return options.ArrayFilters{}, fmt.Errorf("%w: %s", ErrConflictingArrayFilter, f.identifier)
+				}
+				continue
+			}
+			identifiers = append(identifiers, f.identifier)
+			filters[f.identifier] = f.filter
+		}
+	}
+
+	out := options.ArrayFilters{Filters: []interface{}{}}
+	for _, identifier := range identifiers {
+		out.Filters = append(out.Filters, filters[identifier])
+	}
+	return out, nil
+}
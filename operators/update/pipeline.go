@@ -0,0 +1,89 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0B1t322/MongoBuilder/aggregation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrStageNotAllowedInUpdate = errors.New("stage is not allowed in update pipeline")
+
+var allowedInUpdateStages = map[string]bool{
+	"$addFields":   true,
+	"$set":         true,
+	"$project":     true,
+	"$unset":       true,
+	"$replaceRoot": true,
+	"$replaceWith": true,
+}
+
+// Aggregation pipeline that can be used as update, use aggregation package to build the stages
+type PipelineBuilder interface {
+	// Append stages to the end of pipeline
+	Append(stages ...bson.M) PipelineBuilder
+
+	AddFields(args ...aggregation.AddFieldsArger) PipelineBuilder
+	Projection(arg aggregation.ProjectionArger) PipelineBuilder
+	ReplaceRoot(newRoot interface{}) PipelineBuilder
+	ReplaceWith(replaceDocument interface{}) PipelineBuilder
+	Set(args ...aggregation.AddFieldsArger) PipelineBuilder
+	Unset(arg aggregation.UnsetArger) PipelineBuilder
+
+	// Return ErrStageNotAllowedInUpdate if pipeline contains stage that can't be used in update
+	Build() (mongo.Pipeline, error)
+}
+
+type pipeline struct {
+	stages aggregation.PipelineBuilder
+}
+
+func Pipeline(stages ...bson.M) PipelineBuilder {
+	return &pipeline{stages: aggregation.Pipeline(stages...)}
+}
+
+func (p *pipeline) Append(stages ...bson.M) PipelineBuilder {
+	p.stages.Append(stages...)
+	return p
+}
+
+func (p *pipeline) AddFields(args ...aggregation.AddFieldsArger) PipelineBuilder {
+	return p.Append(aggregation.AddFields(args...))
+}
+
+func (p *pipeline) Projection(arg aggregation.ProjectionArger) PipelineBuilder {
+	return p.Append(aggregation.Projection(arg))
+}
+
+func (p *pipeline) ReplaceRoot(newRoot interface{}) PipelineBuilder {
+	return p.Append(aggregation.ReplaceRoot(newRoot))
+}
+
+func (p *pipeline) ReplaceWith(replaceDocument interface{}) PipelineBuilder {
+	return p.Append(aggregation.ReplaceWith(replaceDocument))
+}
+
+func (p *pipeline) Set(args ...aggregation.AddFieldsArger) PipelineBuilder {
+	return p.Append(aggregation.Set(args...))
+}
+
+func (p *pipeline) Unset(arg aggregation.UnsetArger) PipelineBuilder {
+	return p.Append(aggregation.Unset(arg))
+}
+
+func (p *pipeline) Build() (mongo.Pipeline, error) {
+	out, err := p.stages.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// stages of built pipeline contain exactly one field
+	for i, stage := range out {
+		if name := stage[0].Key; !allowedInUpdateStages[name] {
+			return nil, fmt.Errorf("%w: %s at position %d", ErrStageNotAllowedInUpdate, name, i)
+		}
+	}
+	return out, nil
+}
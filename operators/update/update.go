@@ -288,6 +288,93 @@ func Mul(args ...MulArger) bson.M {
 	}
 }
 
+type BitNumber interface {
+	getBitNumber() interface{}
+}
+
+type bitNumber struct {
+	number interface{}
+}
+
+func (b bitNumber) getBitNumber() interface{} {
+	return b.number
+}
+
+func BitInt32(v int32) bitNumber {
+	return bitNumber{
+		number: v,
+	}
+}
+
+func BitInt64(v int64) bitNumber {
+	return bitNumber{
+		number: v,
+	}
+}
+
+type bitArg struct {
+	Field      string
+	Operations bson.M
+}
+
+// field without operations is omitted
+func (b bitArg) formatBitArg() bson.M {
+	if len(b.Operations) == 0 {
+		return bson.M{}
+	}
+	return bson.M{b.Field: b.Operations}
+}
+
+func (b bitArg) withOperation(operation string, number BitNumber) bitArg {
+	b.Operations = utils.MergeBsonM(b.Operations, bson.M{operation: number.getBitNumber()})
+	return b
+}
+
+func (b bitArg) And(number BitNumber) bitArg {
+	return b.withOperation("and", number)
+}
+
+func (b bitArg) Or(number BitNumber) bitArg {
+	return b.withOperation("or", number)
+}
+
+func (b bitArg) Xor(number BitNumber) bitArg {
+	return b.withOperation("xor", number)
+}
+
+type BitArger interface {
+	formatBitArg() bson.M
+}
+
+func BitArg(field string) bitArg {
+	return bitArg{
+		Field:      field,
+		Operations: bson.M{},
+	}
+}
+
+/*
+The $bit operator performs a bitwise update of a field. The operator supports bitwise and, bitwise or, and bitwise xor (i.e. exclusive or) operations.
+To specify a $bit operator expression, use the following prototype:
+	{ $bit: { <field>: { <and|or|xor>: <int> } } }
+Only use this operator with integer fields (either 32-bit integer or 64-bit integer).
+Fields without operations are omitted.
+
+To specify a <field> in an embedded document or in an array, use dot notation.
+*/
+func Bit(args ...BitArger) bson.M {
+	return bson.M{
+		"$bit": utils.MergeBsonM(
+			func() (slice []bson.M) {
+				for _, a := range args {
+					slice = append(slice, a.formatBitArg())
+				}
+				return slice
+			}()...,
+		),
+	}
+}
+
 type renameArg struct {
 	Field   string
 	NewName string
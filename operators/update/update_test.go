@@ -1,13 +1,17 @@
 package update_test
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/0B1t322/MongoBuilder/aggregation"
+	op "github.com/0B1t322/MongoBuilder/operators/aggregation"
 	"github.com/0B1t322/MongoBuilder/operators/query"
 	"github.com/0B1t322/MongoBuilder/operators/sort"
 	"github.com/0B1t322/MongoBuilder/operators/update"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func TestFunc_Update(t *testing.T) {
@@ -114,6 +118,60 @@ func TestFunc_Update(t *testing.T) {
 		},
 	)
 
+	t.Run(
+		"Bit",
+		func(t *testing.T) {
+			flags := update.BitArg("flags")
+			require.Equal(
+				t,
+				bson.M{
+					"$bit": bson.M{
+						"expdata": bson.M{
+							"and": int32(10),
+						},
+						"flags": bson.M{
+							"or":  int64(5),
+							"xor": int64(1),
+						},
+					},
+				},
+				update.Bit(
+					update.BitArg("expdata").And(update.BitInt32(10)),
+					flags.Or(update.BitInt64(5)).Xor(update.BitInt64(1)),
+				),
+			)
+
+			// operations don't change the original arg
+			require.Equal(
+				t,
+				bson.M{
+					"$bit": bson.M{
+						"flags": bson.M{
+							"and": int64(3),
+						},
+					},
+				},
+				update.Bit(flags.And(update.BitInt64(3))),
+			)
+
+			// fields without operations are omitted
+			require.Equal(
+				t,
+				bson.M{
+					"$bit": bson.M{
+						"expdata": bson.M{
+							"or": int32(1),
+						},
+					},
+				},
+				update.Bit(
+					flags,
+					update.BitArg("expdata").Or(update.BitInt32(1)),
+				),
+			)
+		},
+	)
+
 	t.Run(
 		"Rename",
 		func(t *testing.T) {
@@ -455,3 +513,157 @@ func TestFunc_Update(t *testing.T) {
 		},
 	)
 }
+
+func TestFunc_Path(t *testing.T) {
+	t.Run(
+		"Positional",
+		func(t *testing.T) {
+			require.Equal(t, "grades.$", update.Path("grades").Positional().String())
+			require.Equal(t, "grades.$[].questions", update.Path("grades").AllPositional().Field("questions").String())
+			require.Equal(
+				t,
+				bson.M{
+					"$set": bson.M{
+						"grades.$": 82,
+					},
+				},
+				update.Set(update.SetArg(update.Path("grades").Positional().String(), 82)),
+			)
+		},
+	)
+
+	t.Run(
+		"SharedPrefix",
+		func(t *testing.T) {
+			base := update.Path("grades")
+			all := base.AllPositional().Field("x")
+			field := base.Field("y")
+			filtered := base.FilteredPositional("elem", query.GTE("elem.grade", 85))
+			mean := filtered.Field("mean")
+			std := filtered.Field("std")
+
+			require.Equal(t, "grades", base.String())
+			require.Equal(t, "grades.$[].x", all.String())
+			require.Equal(t, "grades.y", field.String())
+			require.Equal(t, "grades.$[elem].mean", mean.String())
+			require.Equal(t, "grades.$[elem].std", std.String())
+
+			filters, err := update.ArrayFilters(base, all, field)
+			require.NoError(t, err)
+			require.Empty(t, filters.Filters)
+
+			filters, err = update.ArrayFilters(mean, std)
+			require.NoError(t, err)
+			require.Equal(t, []interface{}{query.GTE("elem.grade", 85)}, filters.Filters)
+		},
+	)
+
+	t.Run(
+		"ArrayFilters",
+		func(t *testing.T) {
+			grade := update.Path("grades").
+				FilteredPositional("elem", query.GTE("elem.grade", 85)).
+				Field("mean")
+			std := update.Path("grades").
+				FilteredPositional("elem", query.GTE("elem.grade", 85)).
+				Field("std")
+			unset := update.Path("items").
+				FilteredPositional("item", query.EQ("item", "deprecated"))
+
+			require.Equal(t, "grades.$[elem].mean", grade.String())
+			require.Equal(
+				t,
+				bson.M{
+					"$unset": bson.M{
+						"items.$[item]": "",
+					},
+				},
+				update.Unset(update.UnsetArg(unset.String())),
+			)
+
+			filters, err := update.ArrayFilters(grade, std, unset)
+			require.NoError(t, err)
+			require.Equal(
+				t,
+				[]interface{}{
+					bson.M{
+						"elem.grade": bson.M{"$gte": 85},
+					},
+					bson.M{
+						"item": bson.M{"$eq": "deprecated"},
+					},
+				},
+				filters.Filters,
+			)
+
+			// the same identifier with different filters
+			_, err = update.ArrayFilters(
+				grade,
+				update.Path("grades").
+					FilteredPositional("elem", query.LTE("elem.std", 5)).
+					Field("std"),
+			)
+			require.True(t, errors.Is(err, update.ErrConflictingArrayFilter))
+
+			_, err = update.ArrayFilters(
+				update.Path("a").FilteredPositional("e", query.Or(query.EQ("e.a", 1), query.EQ("e.b", 2))),
+				update.Path("b").FilteredPositional("e", query.Or(query.EQ("e.b", 5))),
+			)
+			require.True(t, errors.Is(err, update.ErrConflictingArrayFilter))
+
+			_, err = update.ArrayFilters(
+				update.Path("grades").FilteredPositional("Elem", query.GTE("Elem.grade", 85)),
+			)
+			require.True(t, errors.Is(err, update.ErrInvalidIdentifier))
+
+			_, err = update.ArrayFilters(
+				update.Path("grades").FilteredPositional("elem", query.GTE("grade", 85)),
+			)
+			require.True(t, errors.Is(err, update.ErrInvalidArrayFilter))
+		},
+	)
+}
+
+func TestFunc_Pipeline(t *testing.T) {
+	t.Run(
+		"Build",
+		func(t *testing.T) {
+			pipeline, err := update.Pipeline().
+				Set(
+					aggregation.AddFieldArg().
+						AddField(
+							"grade",
+							op.Cond(op.GTE("$average", 90), "A", "B"),
+						),
+				).
+				Unset(aggregation.UnsetArg("average")).
+				Build()
+			require.NoError(t, err)
+			require.Equal(
+				t,
+				mongo.Pipeline{
+					{{Key: "$set", Value: bson.M{
+						"grade": bson.M{
+							"$cond": bson.A{bson.M{"$gte": bson.A{"$average", 90}}, "A", "B"},
+						},
+					}}},
+					{{Key: "$unset", Value: "average"}},
+				},
+				pipeline,
+			)
+		},
+	)
+
+	t.Run(
+		"StageNotAllowed",
+		func(t *testing.T) {
+			_, err := update.Pipeline(aggregation.Match(query.EQField("status", "A"))).
+				ReplaceWith("$doc").
+				Build()
+			require.True(t, errors.Is(err, update.ErrStageNotAllowedInUpdate))
+
+			_, err = update.Pipeline(bson.M{"$set": bson.M{}, "$unset": "a"}).Build()
+			require.True(t, errors.Is(err, aggregation.ErrInvalidStage))
+		},
+	)
+}
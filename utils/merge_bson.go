@@ -6,7 +6,7 @@ func MergeBsonM(ms ...bson.M) bson.M {
 	if len(ms) == 0 {
 		return bson.M{}
 	}
-	
+
 	out := bson.M{}
 	{
 		if len(ms) > 1 {
@@ -35,3 +35,39 @@ func MergeBsonM(ms ...bson.M) bson.M {
 	}
 	return out
 }
+
+// Work like MergeBsonM but keep order of keys:
+// keys are ordered by first appearance, a value of existing key is replaced in place.
+// Nested bson.D are merged with MergeBsonD and nested bson.M with MergeBsonM
+func MergeBsonD(ds ...bson.D) bson.D {
+	out := bson.D{}
+	{
+		indexes := map[string]int{}
+		for _, d := range ds {
+			for _, e := range d {
+				i, ok := indexes[e.Key]
+				if !ok {
+					indexes[e.Key] = len(out)
+					out = append(out, e)
+					continue
+				}
+				out[i].Value = mergeValues(out[i].Value, e.Value)
+			}
+		}
+	}
+	return out
+}
+
+func mergeValues(old, new interface{}) interface{} {
+	switch new := new.(type) {
+	case bson.D:
+		if old, ok := old.(bson.D); ok {
+			return MergeBsonD(old, new)
+		}
+	case bson.M:
+		if old, ok := old.(bson.M); ok {
+			return MergeBsonM(old, new)
+		}
+	}
+	return new
+}
@@ -75,4 +75,38 @@ func TestFunc_MergeBson(t *testing.T) {
 		bson.M{},
 		utils.MergeBsonM(),
 	)
-}
\ No newline at end of file
+}
+func TestFunc_MergeBsonD(t *testing.T) {
+	require.Equal(
+		t,
+		bson.D{
+			{Key: "b", Value: 1},
+			{Key: "a", Value: bson.D{
+				{Key: "y", Value: 3},
+				{Key: "x", Value: 2},
+			}},
+			{Key: "c", Value: 4},
+		},
+		utils.MergeBsonD(
+			bson.D{
+				{Key: "b", Value: 10},
+				{Key: "a", Value: bson.D{
+					{Key: "y", Value: 3},
+				}},
+			},
+			bson.D{
+				{Key: "a", Value: bson.D{
+					{Key: "x", Value: 2},
+				}},
+				{Key: "c", Value: 4},
+				{Key: "b", Value: 1},
+			},
+		),
+	)
+
+	require.Equal(
+		t,
+		bson.D{},
+		utils.MergeBsonD(),
+	)
+}